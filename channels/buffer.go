@@ -0,0 +1,51 @@
+package channels
+
+import "time"
+
+// BufferPoint 是 BufferSweep 里一种容量的结果
+type BufferPoint struct {
+	Size int
+	// 把 items 个数据从生产者传给消费者的总耗时
+	Nanos int64
+	// 发送前缓冲区已满的次数, 即大概需要阻塞的次数
+	ProducerStalls int64
+}
+
+// BufferSweep 对 sizes 里的每个容量跑一遍单生产者单消费者, 传 items 个数据, 返回每个容量的耗时和生产者阻塞的次数
+//
+// 缓冲区满了发送方要 gopark, 等接收方腾出位置再 goready, 每次都是一次调度. 缓冲区越大阻塞越少, 但是到了一定大小之后
+// 生产者和消费者的速度差不多, 再大也省不了多少. size == 0 是无缓冲的 channel, len 和 cap 都是0, 每次发送都算阻塞,
+// 要等接收方来直接交接. 阻塞是发送前比较 len(ch) == cap(ch) 判断的, 和接收方是并发的, 所以只是个近似值. size < 0 当成0
+func BufferSweep(sizes []int, items int) []BufferPoint {
+	points := make([]BufferPoint, 0, len(sizes))
+	for _, size := range sizes {
+		if size < 0 {
+			size = 0
+		}
+		nanos, stalls := pipeline(size, items)
+		points = append(points, BufferPoint{Size: size, Nanos: nanos, ProducerStalls: stalls})
+	}
+	return points
+}
+
+func pipeline(size, items int) (nanos, stalls int64) {
+	ch := make(chan int, size)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for range ch {
+		}
+	}()
+
+	start := time.Now()
+	for i := 0; i < items; i++ {
+		if len(ch) == cap(ch) {
+			stalls++
+		}
+		ch <- i
+	}
+	close(ch)
+	<-done
+	return time.Since(start).Nanoseconds(), stalls
+}
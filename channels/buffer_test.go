@@ -0,0 +1,31 @@
+package channels
+
+import (
+	"testing"
+
+	"github.com/chidaren/read-go-source-code/schedule"
+)
+
+func TestBufferSweep(t *testing.T) {
+	schedule.LeakCheck(t)
+	const items = 100000
+	points := BufferSweep([]int{0, 64, -1}, items)
+	if len(points) != 3 {
+		t.Fatalf("got %d points, want 3", len(points))
+	}
+	unbuffered, buffered, negative := points[0], points[1], points[2]
+
+	// 无缓冲的 channel 每次发送都要等接收方
+	if unbuffered.ProducerStalls != items {
+		t.Errorf("size 0: ProducerStalls = %d, want %d", unbuffered.ProducerStalls, items)
+	}
+	if buffered.ProducerStalls >= unbuffered.ProducerStalls {
+		t.Errorf("size 64 stalls %d, not fewer than unbuffered %d", buffered.ProducerStalls, unbuffered.ProducerStalls)
+	}
+	if unbuffered.Nanos <= buffered.Nanos {
+		t.Errorf("size 0 took %dns, size 64 took %dns, want unbuffered slower", unbuffered.Nanos, buffered.Nanos)
+	}
+	if negative.Size != 0 {
+		t.Errorf("size -1 reported as %d, want 0", negative.Size)
+	}
+}
@@ -0,0 +1,88 @@
+// Package channels 观察 runtime/chan.go 里 hchan 的行为
+package channels
+
+import "runtime"
+
+/*
+
+有缓冲的 channel 在 hchan 里用一个长度为 dataqsiz 的环形数组 buf 保存数据, sendx 是下一次写的位置, recvx 是下一次读的位置,
+到了 dataqsiz 就绕回0. qcount == dataqsiz 的时候发送方阻塞, 挂到 sendq 上; qcount == 0 的时候接收方阻塞, 挂到 recvq 上.
+不管怎么绕, 读出来的顺序和写进去的顺序都是一样的
+
+*/
+
+// RingReport 是 RingBehavior 的结果
+type RingReport struct {
+	// 收到的值是不是和发送的顺序完全一致
+	OrderPreserved bool
+	// 发送时缓冲区已满(需要阻塞)的次数
+	SendBlocks int
+	// 接收时缓冲区为空(需要阻塞)的次数
+	RecvBlocks int
+}
+
+// RingBehavior 往容量为 capacity 的 channel 里依次发送 0..ops-1, 接收方每次只取一半左右就停下来让出P,
+// 让发送方把缓冲区重新填满, 这样 sendx, recvx 会反复绕过数组的末尾
+//
+// 是否阻塞是用 select 的 default 分支判断的: 走了 default 就说明这次操作会阻塞, 计数之后再正常地阻塞发送/接收.
+// capacity == 0 的时候每一次发送都要等接收方来交接, 都算阻塞. 和 BufferSweep 一样 capacity < 0 当成0
+func RingBehavior(capacity, ops int) RingReport {
+	if capacity < 0 {
+		capacity = 0
+	}
+	r := RingReport{OrderPreserved: true}
+	ch := make(chan int, capacity)
+
+	sendBlocks := make(chan int, 1)
+	go func() {
+		blocks := 0
+		for i := 0; i < ops; i++ {
+			if capacity == 0 {
+				blocks++
+				ch <- i
+				continue
+			}
+			select {
+			case ch <- i:
+			default:
+				blocks++
+				ch <- i
+			}
+		}
+		close(ch)
+		sendBlocks <- blocks
+	}()
+
+	burst := capacity/2 + 1
+	next := 0
+	for open := true; open; {
+		for i := 0; i < burst; i++ {
+			var (
+				v  int
+				ok bool
+			)
+			select {
+			case v, ok = <-ch:
+			default:
+				r.RecvBlocks++
+				v, ok = <-ch
+			}
+			if !ok {
+				open = false
+				break
+			}
+			if v != next {
+				r.OrderPreserved = false
+			}
+			next++
+		}
+		// 让发送方把缓冲区填满
+		runtime.Gosched()
+	}
+
+	r.SendBlocks = <-sendBlocks
+	if next != ops {
+		r.OrderPreserved = false
+	}
+	return r
+}
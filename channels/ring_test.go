@@ -0,0 +1,36 @@
+package channels
+
+import (
+	"testing"
+
+	"github.com/chidaren/read-go-source-code/schedule"
+)
+
+func TestRingBehavior(t *testing.T) {
+	schedule.LeakCheck(t)
+	const ops = 1000
+	for _, capacity := range []int{1, 4, 7} {
+		r := RingBehavior(capacity, ops)
+		if !r.OrderPreserved {
+			t.Errorf("capacity=%d: order not preserved", capacity)
+		}
+		if r.SendBlocks > ops {
+			t.Errorf("capacity=%d: SendBlocks = %d, more than %d sends", capacity, r.SendBlocks, ops)
+		}
+	}
+}
+
+// 无缓冲的 channel 没有 buf, 每次发送都要等接收方, 都算阻塞
+func TestRingBehaviorUnbuffered(t *testing.T) {
+	schedule.LeakCheck(t)
+	const ops = 1000
+	for _, capacity := range []int{0, -1} {
+		r := RingBehavior(capacity, ops)
+		if !r.OrderPreserved {
+			t.Errorf("capacity=%d: order not preserved", capacity)
+		}
+		if r.SendBlocks != ops {
+			t.Errorf("capacity=%d: SendBlocks = %d, want %d", capacity, r.SendBlocks, ops)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package channels
+
+import (
+	"fmt"
+	"reflect"
+)
+
+/*
+
+selectgo 开始的时候用 cheaprandn 生成一个随机的 pollorder, 按这个顺序检查每个 case, 第一个能执行的就被选中,
+所以多个 case 同时就绪的时候每个被选中的概率是一样的, 不会因为写在前面就一直被选中
+
+*/
+
+// SelectFairness 建 cases 个一直可读的 channel, 对它们 select rounds 次, 返回每个 case 被选中的次数
+//
+// case 的个数是运行时才知道的, 所以用 reflect.Select 动态构造. cases < 1 返回错误
+func SelectFairness(cases, rounds int) ([]int, error) {
+	if cases < 1 {
+		return nil, fmt.Errorf("channels: SelectFairness needs at least 1 case, got %d", cases)
+	}
+
+	counts := make([]int, cases)
+	sel := make([]reflect.SelectCase, cases)
+	for i := range sel {
+		// 关闭的 channel 永远可读
+		ch := make(chan struct{})
+		close(ch)
+		sel[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)}
+	}
+
+	for i := 0; i < rounds; i++ {
+		chosen, _, _ := reflect.Select(sel)
+		counts[chosen]++
+	}
+	return counts, nil
+}
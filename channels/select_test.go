@@ -0,0 +1,50 @@
+package channels
+
+import (
+	"testing"
+
+	"github.com/chidaren/read-go-source-code/schedule"
+)
+
+func TestSelectFairness(t *testing.T) {
+	schedule.LeakCheck(t)
+	const cases, rounds = 4, 40000
+	counts, err := SelectFairness(cases, rounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != cases {
+		t.Fatalf("len(counts) = %d, want %d", len(counts), cases)
+	}
+
+	// 卡方检验, 自由度 cases-1=3, p=0.001 的临界值是 16.27, 均匀分布的时候误报的概率只有千分之一
+	expected := float64(rounds) / cases
+	var chi2 float64
+	total := 0
+	for _, c := range counts {
+		d := float64(c) - expected
+		chi2 += d * d / expected
+		total += c
+	}
+	if total != rounds {
+		t.Errorf("sum(counts) = %d, want %d", total, rounds)
+	}
+	if chi2 > 16.27 {
+		t.Errorf("counts %v, chi-square %.2f > 16.27, not uniform", counts, chi2)
+	}
+}
+
+func TestSelectFairnessEdgeCases(t *testing.T) {
+	for _, cases := range []int{0, -1} {
+		if _, err := SelectFairness(cases, 10); err == nil {
+			t.Errorf("SelectFairness(%d, 10) succeeded, want an error", cases)
+		}
+	}
+	counts, err := SelectFairness(3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 3 || counts[0] != 0 || counts[1] != 0 || counts[2] != 0 {
+		t.Errorf("rounds=0: counts = %v, want [0 0 0]", counts)
+	}
+}
@@ -0,0 +1,102 @@
+// gmp 运行 schedule 包里的演示, 默认是 starvation, 参数和最早的 gmp.go 一样: 一个P, 跑一个小时
+//
+//	gmp [-demo starvation|preempt|sysmon|worksteal|handoff|latency] [-procs 1] [-d 1h] [-json] [-v]
+//
+// Ctrl-C(SIGINT) 或者 SIGTERM 会提前结束 starvation, preempt, sysmon, latency, 打印统计之后正常退出, worksteal 和 handoff 的工作量是固定的, 很快就结束.
+// -json 的时候把结果以 JSON 输出到 stdout, starvation 过程中的打印改到 stderr.
+// -v 的时候把调度事件(G的创建, Gosched, 到期, 退出)以 slog 文本格式打印到 stderr
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/chidaren/read-go-source-code/schedule"
+)
+
+var (
+	demo     = flag.String("demo", "starvation", "demo to run: starvation, preempt, sysmon, worksteal, handoff, latency")
+	procs    = flag.Int("procs", 1, "GOMAXPROCS for the demo")
+	duration = flag.Duration("d", time.Hour, "how long the demo runs")
+	asJSON   = flag.Bool("json", false, "print the result as JSON")
+	verbose  = flag.Bool("v", false, "log scheduler events to stderr")
+)
+
+func main() {
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	opts := schedule.Options{
+		Procs:    *procs,
+		Duration: *duration,
+	}
+	if *asJSON {
+		opts.Output = os.Stderr
+	}
+	if *verbose {
+		opts.Logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	}
+	if err := opts.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if !schedule.IsWarning(err) {
+			os.Exit(2)
+		}
+	}
+
+	r, err := run(ctx, *demo, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		b, err := json.Marshal(r)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println(string(b))
+		return
+	}
+	printText(os.Stdout, r)
+}
+
+func run(ctx context.Context, name string, opts schedule.Options) (schedule.Report, error) {
+	switch name {
+	case "starvation":
+		return schedule.StarvationDemo(ctx, opts)
+	case "preempt":
+		return schedule.AsyncPreempt(ctx, opts.Procs, opts.Duration)
+	case "sysmon":
+		return schedule.SysmonPreempt(ctx, opts.Duration)
+	case "worksteal":
+		return schedule.WorkSteal(100, opts.Procs)
+	case "handoff":
+		return schedule.DirectHandoff(100000)
+	case "latency":
+		return schedule.LatencyHistogram(ctx, opts.Procs, 4, opts.Duration)
+	}
+	return nil, fmt.Errorf("unknown demo %q", name)
+}
+
+func printText(w io.Writer, r schedule.Report) {
+	switch r := r.(type) {
+	case schedule.StarvationReport:
+		fmt.Fprintf(w, "total increments: %d\n", r.Increments)
+		fmt.Fprintf(w, "observer prints: %d\n", r.ObserverRuns)
+	case schedule.Histogram:
+		fmt.Fprintf(w, "samples: %d p50: %v p99: %v max: %v\n", r.Total, r.P50(), r.P99(), r.Max)
+	default:
+		fmt.Fprintf(w, "%+v\n", r)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// runMainEnv 设置之后测试二进制直接跑 main, 用来在子进程里测试信号处理
+const runMainEnv = "GMP_TEST_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestSignalStopsStarvation(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no SIGINT/SIGTERM delivery on windows")
+	}
+	for _, sig := range []syscall.Signal{syscall.SIGINT, syscall.SIGTERM} {
+		t.Run(sig.String(), func(t *testing.T) {
+			exe, err := os.Executable()
+			if err != nil {
+				t.Fatal(err)
+			}
+			cmd := exec.Command(exe, "-demo", "starvation", "-procs", "1", "-d", "1h")
+			cmd.Env = append(os.Environ(), runMainEnv+"=1")
+			stdout, err := cmd.StdoutPipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var stderr bytes.Buffer
+			cmd.Stderr = &stderr
+			if err := cmd.Start(); err != nil {
+				t.Fatal(err)
+			}
+			timer := time.AfterFunc(30*time.Second, func() { cmd.Process.Kill() })
+			defer timer.Stop()
+
+			// 等 B 第一次打印, 说明演示已经跑起来了, 信号处理也已经装好
+			r := bufio.NewReader(stdout)
+			if _, err := r.ReadString('\n'); err != nil {
+				t.Fatalf("waiting for first print: %v\n%s", err, stderr.Bytes())
+			}
+			if err := cmd.Process.Signal(sig); err != nil {
+				t.Fatal(err)
+			}
+			rest, _ := io.ReadAll(r)
+			if err := cmd.Wait(); err != nil {
+				t.Fatalf("exit after %v: %v\n%s", sig, err, stderr.Bytes())
+			}
+
+			out := string(rest)
+			for _, want := range []string{"total increments: ", "observer prints: "} {
+				if !strings.Contains(out, want) {
+					t.Errorf("output missing %q:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
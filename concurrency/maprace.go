@@ -0,0 +1,182 @@
+// Package concurrency 演示 runtime 里和并发安全相关的行为
+package concurrency
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+
+内置的 map 不是并发安全的, runtime 在 mapassign 的时候会设置 hashWriting 标记, 另一个G同时写(或者读)发现这个标记,
+就会 fatal("concurrent map writes"). fatal 和 panic 不同, recover 不了, 整个进程直接退出,
+所以 unsafe 模式只能放到子进程里跑, 父进程检查子进程的退出状态和输出
+
+*/
+
+const (
+	mapRaceEnv = "CONCURRENCY_MAPRACE_CHILD"
+
+	mapWriters       = 8
+	mapKeysPerWriter = 1000
+	mapReaders       = 4
+
+	// 子进程最多跑这么久, 还没崩就正常退出
+	unsafeChildTimeout = 5 * time.Second
+)
+
+// MapRaceResult 是 MapRace 的结果
+type MapRaceResult struct {
+	Mode string
+	// mutex 和 sync.Map 模式下最终 map 的大小, 应该等于 ExpectedSize
+	Size         int
+	ExpectedSize int
+	// unsafe 模式下子进程是不是因为并发读写 map 挂掉了(fatal error 或者 -race 报告)
+	Fatal bool
+	// unsafe 模式下子进程的输出
+	ChildOutput string
+}
+
+func init() {
+	if os.Getenv(mapRaceEnv) != "" {
+		unsafeMapWrites()
+		os.Exit(0)
+	}
+}
+
+// MapRace 用 mode 指定的方式让多个G同时读写一个 map, mode 可以是:
+//
+//	"unsafe"   直接读写内置 map, 在子进程里跑, 预期子进程 fatal
+//	"mutex"    用 sync.RWMutex 保护内置 map
+//	"sync.Map" 用 sync.Map
+func MapRace(mode string) (MapRaceResult, error) {
+	r := MapRaceResult{Mode: mode, ExpectedSize: mapWriters * mapKeysPerWriter}
+	switch mode {
+	case "unsafe":
+		out, fatal, err := runUnsafeChild()
+		r.ChildOutput, r.Fatal = out, fatal
+		return r, err
+	case "mutex":
+		r.Size = mutexMap()
+	case "sync.Map":
+		r.Size = syncMap()
+	default:
+		return r, fmt.Errorf("concurrency: unknown MapRace mode %q", mode)
+	}
+	return r, nil
+}
+
+// runUnsafeChild 重新执行当前的可执行文件(go test 的时候就是测试二进制), 由 init 拦截下来跑 unsafeMapWrites
+func runUnsafeChild() (output string, fatal bool, err error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", false, err
+	}
+	cmd := exec.Command(exe)
+	cmd.Env = append(os.Environ(), mapRaceEnv+"=1")
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+
+	runErr := cmd.Run()
+	output = buf.String()
+	if _, ok := runErr.(*exec.ExitError); runErr != nil && !ok {
+		return output, false, runErr
+	}
+	fatal = runErr != nil && (strings.Contains(output, "concurrent map") || strings.Contains(output, "DATA RACE"))
+	return output, fatal, nil
+}
+
+// unsafeMapWrites 在子进程里跑, 多个G不加锁地写同一个 map
+func unsafeMapWrites() {
+	// 至少两个P, 单核机器上也能让两个线程真正交错执行
+	if runtime.GOMAXPROCS(0) < 2 {
+		runtime.GOMAXPROCS(2)
+	}
+
+	m := make(map[int]int)
+	deadline := time.Now().Add(unsafeChildTimeout)
+
+	var wg sync.WaitGroup
+	wg.Add(mapWriters)
+	for w := 0; w < mapWriters; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; time.Now().Before(deadline); i++ {
+				m[w*mapKeysPerWriter+i%mapKeysPerWriter] = i
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+func mutexMap() int {
+	var (
+		mu sync.RWMutex
+		m  = make(map[int]int)
+		wg sync.WaitGroup
+	)
+
+	wg.Add(mapWriters + mapReaders)
+	for w := 0; w < mapWriters; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < mapKeysPerWriter; i++ {
+				mu.Lock()
+				m[w*mapKeysPerWriter+i] = i
+				mu.Unlock()
+			}
+		}(w)
+	}
+	for r := 0; r < mapReaders; r++ {
+		go func() {
+			defer wg.Done()
+			for i := 0; i < mapKeysPerWriter; i++ {
+				mu.RLock()
+				_ = m[i]
+				mu.RUnlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return len(m)
+}
+
+func syncMap() int {
+	var (
+		m  sync.Map
+		wg sync.WaitGroup
+	)
+
+	wg.Add(mapWriters + mapReaders)
+	for w := 0; w < mapWriters; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < mapKeysPerWriter; i++ {
+				m.Store(w*mapKeysPerWriter+i, i)
+			}
+		}(w)
+	}
+	for r := 0; r < mapReaders; r++ {
+		go func() {
+			defer wg.Done()
+			for i := 0; i < mapKeysPerWriter; i++ {
+				m.Load(i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	n := 0
+	m.Range(func(_, _ interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
@@ -0,0 +1,44 @@
+package concurrency
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chidaren/read-go-source-code/schedule"
+)
+
+// unsafe 模式会重新执行测试二进制, 子进程在 init 里被拦截, 预期因为并发写 map 挂掉
+func TestMapRaceUnsafe(t *testing.T) {
+	schedule.LeakCheck(t)
+	r, err := MapRace("unsafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Fatal {
+		t.Fatalf("child did not crash, output:\n%s", r.ChildOutput)
+	}
+	if !strings.Contains(r.ChildOutput, "concurrent map") && !strings.Contains(r.ChildOutput, "DATA RACE") {
+		t.Errorf("child output has no map fatal error or race report:\n%s", r.ChildOutput)
+	}
+}
+
+func TestMapRaceSafeModes(t *testing.T) {
+	schedule.LeakCheck(t)
+	for _, mode := range []string{"mutex", "sync.Map"} {
+		t.Run(mode, func(t *testing.T) {
+			r, err := MapRace(mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Fatal || r.Size != r.ExpectedSize {
+				t.Errorf("%s: Size = %d, Fatal = %v, want Size %d", mode, r.Size, r.Fatal, r.ExpectedSize)
+			}
+		})
+	}
+}
+
+func TestMapRaceUnknownMode(t *testing.T) {
+	if _, err := MapRace("rwlock"); err == nil {
+		t.Error("unknown mode accepted")
+	}
+}
@@ -0,0 +1,73 @@
+package gc
+
+import (
+	"math"
+	"runtime"
+	"runtime/debug"
+)
+
+const (
+	// memChunk 是 MemLimitDemo 每次分配的大小
+	memChunk = 64 << 10
+	// 每分配这么多个 chunk 采样一次堆的大小
+	memSampleEvery = 16
+	// 堆的峰值超过软限制 10% 以内都算遵守了
+	memLimitSlack = 1.1
+)
+
+// MemLimitReport 是 MemLimitDemo 的结果
+type MemLimitReport struct {
+	NumGC int
+	// 采样到的最大 HeapAlloc
+	HeapPeak uint64
+	// HeapPeak 是否在限制附近以内, 没有限制的时候总是 true
+	LimitRespected bool
+}
+
+// MemLimitDemo 用 debug.SetMemoryLimit 设置 limitBytes 的软限制, 然后总共分配 allocTarget 字节, 始终保留最近 1/4 的分配不释放
+//
+// go1.19 之后 GC 的触发点除了 GOGC 算出来的目标, 还受内存限制约束: 快到限制的时候 GC 会提前, 更频繁地触发,
+// 所以同样的分配量, 限制越小 NumGC 越大, 堆的峰值也被压在限制附近. 这是软限制, 存活的对象本身超过限制
+// 的时候 GC 也没办法, 所以保留的部分最多是 limitBytes 的 1/4, 剩下的空间留给垃圾和 runtime 自己.
+// limitBytes <= 0 表示不限制(math.MaxInt64). 返回前恢复原来的限制
+func MemLimitDemo(limitBytes int64, allocTarget int64) MemLimitReport {
+	if limitBytes <= 0 {
+		limitBytes = math.MaxInt64
+	}
+	prev := debug.SetMemoryLimit(limitBytes)
+	defer debug.SetMemoryLimit(prev)
+
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	startGC := ms.NumGC
+
+	var peak uint64
+	chunks := int(allocTarget / memChunk)
+	retained := allocTarget / 4
+	if limit := limitBytes / 4; limit < retained {
+		retained = limit
+	}
+	// 环形的保留窗口, 新的 chunk 覆盖掉最老的, 被覆盖的才变成垃圾
+	window := make([][]byte, retained/memChunk+1)
+	for i := 0; i < chunks; i++ {
+		window[i%len(window)] = make([]byte, memChunk)
+		if i%memSampleEvery == 0 {
+			runtime.ReadMemStats(&ms)
+			if ms.HeapAlloc > peak {
+				peak = ms.HeapAlloc
+			}
+		}
+	}
+	runtime.ReadMemStats(&ms)
+	if ms.HeapAlloc > peak {
+		peak = ms.HeapAlloc
+	}
+	runtime.KeepAlive(window)
+
+	return MemLimitReport{
+		NumGC:          int(ms.NumGC - startGC),
+		HeapPeak:       peak,
+		LimitRespected: limitBytes == math.MaxInt64 || float64(peak) <= float64(limitBytes)*memLimitSlack,
+	}
+}
@@ -0,0 +1,24 @@
+package gc
+
+import (
+	"testing"
+
+	"github.com/chidaren/read-go-source-code/schedule"
+)
+
+func TestMemLimitDemo(t *testing.T) {
+	schedule.LeakCheck(t)
+	// 分配量是限制的8倍, 保留的部分被压在限制的 1/4, 堆的峰值应该在限制附近
+	limited := MemLimitDemo(32<<20, 256<<20)
+	if !limited.LimitRespected {
+		t.Errorf("32MB limit: HeapPeak = %dMB, want <= limit", limited.HeapPeak>>20)
+	}
+
+	unlimited := MemLimitDemo(0, 256<<20)
+	if !unlimited.LimitRespected {
+		t.Error("no limit: LimitRespected = false")
+	}
+	if limited.NumGC < unlimited.NumGC {
+		t.Errorf("NumGC with 32MB limit = %d, without = %d, want the limit to trigger GC at least as often", limited.NumGC, unlimited.NumGC)
+	}
+}
@@ -0,0 +1,96 @@
+// Package gc 观察 GC 的停顿和调步, GC 的 STW 会让所有G都停下来, 和调度的公平性, 延迟直接相关
+package gc
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PauseReport 是 PauseObserver 的结果
+type PauseReport struct {
+	// 观察期间发生的 GC 次数
+	NumGC int
+	// 观察到的最大停顿
+	MaxPauseNs uint64
+	// 观察到的停顿的99分位
+	P99PauseNs uint64
+}
+
+// sink 让分配出来的对象活一小会儿, 不会被编译器优化掉
+var sink [][]byte
+
+// PauseObserver 每毫秒分配 allocRate 个1KB的对象, 持续 d, 同时另一个G定期读 runtime.MemStats 收集这段时间内每次 GC 的停顿
+//
+// MemStats.PauseNs 是一个256项的环形缓冲, 第k次 GC(从1开始)的停顿在 PauseNs[(k+255)%256],
+// 两次读取之间超过256次 GC 的话前面的就被覆盖了, 所以监控的G要读得足够勤
+func PauseObserver(allocRate int, d time.Duration) PauseReport {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	startGC := ms.NumGC
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tick := time.NewTicker(time.Millisecond)
+		defer tick.Stop()
+		keep := make([][]byte, 0, 1024)
+		for {
+			select {
+			case <-stop:
+				sink = keep
+				return
+			case <-tick.C:
+			}
+			for i := 0; i < allocRate; i++ {
+				if len(keep) == cap(keep) {
+					keep = keep[:0]
+				}
+				keep = append(keep, make([]byte, 1024))
+			}
+		}
+	}()
+
+	var pauses []uint64
+	last := startGC
+	collect := func() {
+		runtime.ReadMemStats(&ms)
+		pauses = append(pauses, pausesSince(&ms, last)...)
+		last = ms.NumGC
+	}
+
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		collect()
+	}
+	close(stop)
+	wg.Wait()
+	collect()
+	sink = nil
+
+	r := PauseReport{NumGC: int(last - startGC)}
+	if len(pauses) == 0 {
+		return r
+	}
+	sort.Slice(pauses, func(i, j int) bool { return pauses[i] < pauses[j] })
+	r.MaxPauseNs = pauses[len(pauses)-1]
+	r.P99PauseNs = pauses[(len(pauses)-1)*99/100]
+	return r
+}
+
+// pausesSince 返回第 since+1 次到第 ms.NumGC 次 GC 的停顿, 中间被环形缓冲覆盖掉的拿不到, 只返回最近的256次
+func pausesSince(ms *runtime.MemStats, since uint32) []uint64 {
+	n := ms.NumGC - since
+	if n > uint32(len(ms.PauseNs)) {
+		n = uint32(len(ms.PauseNs))
+	}
+	out := make([]uint64, 0, n)
+	for k := ms.NumGC - n + 1; k <= ms.NumGC; k++ {
+		out = append(out, ms.PauseNs[(k+255)%256])
+	}
+	return out
+}
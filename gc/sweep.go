@@ -0,0 +1,86 @@
+package gc
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// GCSweepPoint 是 SweepGCPercent 的一个采样点
+type GCSweepPoint struct {
+	Percent int
+	// Percent < 0, 即 GOGC=off, GC 被关掉了, 这时 NumGC 是0(除非设置了 GOMEMLIMIT), HeapPeak 只受 workload 分配的总量限制
+	GCOff        bool
+	NumGC        int
+	TotalPauseNs uint64
+	// 采样到的最大 HeapAlloc
+	HeapPeak uint64
+}
+
+// SweepGCPercent 依次用 percents 里的值调用 debug.SetGCPercent, 然后运行 workload, 记录 GC 次数, 总停顿和堆的峰值
+//
+// GOGC 越小, 堆增长到上次存活对象的 (1+GOGC/100) 倍就会触发 GC, GC 更频繁但是堆的峰值更低. 返回前恢复原来的值, percents 为空返回 nil
+func SweepGCPercent(percents []int, workload func()) []GCSweepPoint {
+	if len(percents) == 0 {
+		return nil
+	}
+
+	orig := debug.SetGCPercent(percents[0])
+	defer debug.SetGCPercent(orig)
+
+	points := make([]GCSweepPoint, 0, len(percents))
+	for _, p := range percents {
+		debug.SetGCPercent(p)
+		points = append(points, measureGC(p, workload))
+	}
+	return points
+}
+
+func measureGC(percent int, workload func()) GCSweepPoint {
+	// 先做一次 GC, 不把上一轮的垃圾算到这一轮
+	runtime.GC()
+
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	var (
+		peak uint64
+		stop = make(chan struct{})
+		wg   sync.WaitGroup
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var ms runtime.MemStats
+		for {
+			runtime.ReadMemStats(&ms)
+			if ms.HeapAlloc > peak {
+				peak = ms.HeapAlloc
+			}
+			select {
+			case <-stop:
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+	}()
+
+	workload()
+	close(stop)
+	wg.Wait()
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	if after.HeapAlloc > peak {
+		peak = after.HeapAlloc
+	}
+
+	return GCSweepPoint{
+		Percent:      percent,
+		GCOff:        percent < 0,
+		NumGC:        int(after.NumGC - before.NumGC),
+		TotalPauseNs: after.PauseTotalNs - before.PauseTotalNs,
+		HeapPeak:     peak,
+	}
+}
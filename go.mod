@@ -0,0 +1,9 @@
+module github.com/chidaren/read-go-source-code
+
+go 1.26.0
+
+require (
+	github.com/google/pprof v0.0.0-20260926063103-aaccee046517
+	golang.org/x/exp v0.0.0-20260908205506-85c1c2202aba
+	golang.org/x/sys v0.48.0
+)
@@ -0,0 +1,41 @@
+// Package internals 通过可以运行的小实验观察 runtime 里的一些实现细节
+package internals
+
+import "unsafe"
+
+/*
+
+append 容量不够的时候调用 growslice 重新分配底层数组:
+go1.18 之前, cap < 1024 的时候翻倍, 之后每次增加 1/4.
+go1.18 之后, 阈值改成 256, 超过之后按 newcap += (newcap + 3*256) / 4 平滑地从2倍过渡到1.25倍.
+最后还会按 size class 向上取整(roundupsize), 所以实际的 cap 往往比公式算出来的大一点
+
+*/
+
+// GrowEvent 记录一次底层数组的重新分配
+type GrowEvent struct {
+	// 触发扩容的这次 append 之后的 len
+	Len    int
+	OldCap int
+	NewCap int
+}
+
+// AppendGrowth 从 nil 开始一个一个 append n 个元素, 底层数组的地址变化的时候记录一次 GrowEvent
+//
+// nil 切片没有底层数组, 第一次 append 的分配也算一次扩容(OldCap 为0), n == 0 的时候返回空的 slice
+func AppendGrowth(n int) []GrowEvent {
+	events := []GrowEvent{}
+
+	var s []int
+	var data unsafe.Pointer
+	for i := 0; i < n; i++ {
+		oldCap := cap(s)
+		s = append(s, i)
+		// 第一次 append 之前 data 是 nil, 比较地址就能把它算成一次分配, 不需要特殊处理
+		if p := unsafe.Pointer(unsafe.SliceData(s)); p != data {
+			events = append(events, GrowEvent{Len: len(s), OldCap: oldCap, NewCap: cap(s)})
+			data = p
+		}
+	}
+	return events
+}
@@ -0,0 +1,45 @@
+package internals
+
+import "testing"
+
+func TestAppendGrowthEmpty(t *testing.T) {
+	if ev := AppendGrowth(0); ev == nil || len(ev) != 0 {
+		t.Errorf("AppendGrowth(0) = %#v, want an empty non-nil slice", ev)
+	}
+}
+
+func TestAppendGrowth(t *testing.T) {
+	events := AppendGrowth(10000)
+	if len(events) == 0 {
+		t.Fatal("no grow events")
+	}
+	// 从 nil 开始的第一次 append 只算一次, 之后 OldCap 都不是0
+	if first := events[0]; first.OldCap != 0 || first.Len != 1 || first.NewCap < 1 {
+		t.Errorf("first event = %+v, want {Len:1 OldCap:0 NewCap:>=1}", first)
+	}
+
+	for i, e := range events[1:] {
+		prev := events[i]
+		if e.OldCap == 0 {
+			t.Errorf("event %d = %+v, only the first append from nil has OldCap 0", i+1, e)
+		}
+		if e.OldCap != prev.NewCap || e.Len != e.OldCap+1 {
+			t.Errorf("event %d = %+v after %+v, want a reallocation exactly when the slice is full", i+1, e, prev)
+		}
+		switch {
+		case e.OldCap <= 256:
+			// 小于256的时候翻倍, 等于256的时候公式算出来也正好是2倍. []int 翻倍之后的大小正好都是 size class, 不会再向上取整
+			if e.NewCap != 2*e.OldCap {
+				t.Errorf("event %d = %+v, want cap doubled below 256", i+1, e)
+			}
+		default:
+			// 超过256之后 newcap += (newcap + 3*256) / 4, 再按 size class 向上取整, 增长慢于2倍
+			if min := e.OldCap + (e.OldCap+3*256)/4; e.NewCap < min || e.NewCap >= 2*e.OldCap {
+				t.Errorf("event %d = %+v, want cap in [%d, %d)", i+1, e, min, 2*e.OldCap)
+			}
+		}
+	}
+	if last := events[len(events)-1]; last.NewCap < 10000 {
+		t.Errorf("last event = %+v, cap less than the 10000 appended elements", last)
+	}
+}
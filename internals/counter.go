@@ -0,0 +1,91 @@
+package internals
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+
+原来 gmp.go 里的 count++ 没有任何同步, 一个G写另一个G读, 是 data race, go run -race 会报出来.
+正确的做法有三种, 代价从小到大:
+atomic.AddInt64 是一条 LOCK XADD 指令;
+sync.Mutex 没有竞争的时候也只是一次 CAS, 有竞争的时候要自旋或者 gopark;
+channel 每次发送都要加 hchan.lock, 还可能要唤醒接收的G
+
+*/
+
+// CounterReport 是 CounterBench 的结果
+type CounterReport struct {
+	AtomicNanos  int64
+	MutexNanos   int64
+	ChannelNanos int64
+	// 依次是 atomic, mutex, channel 的最终值, 都应该等于 workers*incrementsPerWorker
+	FinalValues [3]int64
+}
+
+// CounterBench 让 workers 个G各自把计数器加 incrementsPerWorker 次, 分别用 atomic, mutex, channel 三种方式同步.
+// workers <= 0 或者 incrementsPerWorker <= 0 返回空的结果
+func CounterBench(workers, incrementsPerWorker int) CounterReport {
+	var r CounterReport
+	if workers <= 0 || incrementsPerWorker <= 0 {
+		return r
+	}
+
+	var atomicCount int64
+	r.AtomicNanos = timeWorkers(workers, func(int) {
+		for i := 0; i < incrementsPerWorker; i++ {
+			atomic.AddInt64(&atomicCount, 1)
+		}
+	})
+	r.FinalValues[0] = atomic.LoadInt64(&atomicCount)
+
+	var (
+		mu         sync.Mutex
+		mutexCount int64
+	)
+	r.MutexNanos = timeWorkers(workers, func(int) {
+		for i := 0; i < incrementsPerWorker; i++ {
+			mu.Lock()
+			mutexCount++
+			mu.Unlock()
+		}
+	})
+	r.FinalValues[1] = mutexCount
+
+	// 只有 owner 这一个G读写计数器, 其他G通过 channel 告诉它加一
+	incr := make(chan struct{})
+	result := make(chan int64)
+	go func() {
+		var n int64
+		for range incr {
+			n++
+		}
+		result <- n
+	}()
+	r.ChannelNanos = timeWorkers(workers, func(int) {
+		for i := 0; i < incrementsPerWorker; i++ {
+			incr <- struct{}{}
+		}
+	})
+	close(incr)
+	r.FinalValues[2] = <-result
+
+	return r
+}
+
+// timeWorkers 同时启动 workers 个G执行 fn(i), 返回全部结束的耗时
+func timeWorkers(workers int, fn func(i int)) int64 {
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	start := time.Now()
+	for i := 0; i < workers; i++ {
+		go func(i int) {
+			defer wg.Done()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+	return time.Since(start).Nanoseconds()
+}
@@ -0,0 +1,33 @@
+package internals
+
+import (
+	"testing"
+
+	"github.com/chidaren/read-go-source-code/schedule"
+)
+
+func TestCounterBench(t *testing.T) {
+	schedule.LeakCheck(t)
+	const workers, incr = 4, 20000
+	r := CounterBench(workers, incr)
+	for i, v := range r.FinalValues {
+		if v != workers*incr {
+			t.Errorf("FinalValues[%d] = %d, want %d", i, v, workers*incr)
+		}
+	}
+	// 量级上 atomic < mutex < channel, 给 atomic 和 mutex 留一倍的余量, 单核机器上两者可能很接近
+	if r.AtomicNanos > 2*r.MutexNanos || r.AtomicNanos > r.ChannelNanos {
+		t.Errorf("%+v, want atomic to be the fastest", r)
+	}
+	if r.ChannelNanos < r.MutexNanos {
+		t.Errorf("%+v, want channel to be the slowest", r)
+	}
+}
+
+func TestCounterBenchBadArguments(t *testing.T) {
+	for _, args := range [][2]int{{-1, 10}, {0, 10}, {4, -1}} {
+		if r := CounterBench(args[0], args[1]); r != (CounterReport{}) {
+			t.Errorf("CounterBench(%d, %d) = %+v, want an empty report", args[0], args[1], r)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package internals
+
+import "time"
+
+/*
+
+go1.13 之前 defer 都是通过 deferproc 在堆上(1.13 开始可以在栈上)分配 _defer 记录, 函数返回前 deferreturn 依次调用.
+go1.14 之后没有在循环里的 defer 会被编译成 open-coded defer: 直接把调用内联到函数的每个出口, 用一个 bitmask 记录哪些 defer 要执行,
+开销和普通函数调用差不多. 而 panic 要走 gopanic, 扫描栈帧找到 defer 并执行, recover 之后再通过 recovery 恢复到 deferreturn, 代价大得多
+
+*/
+
+// DeferReport 是 DeferCost 的结果, 都是每次迭代的纳秒数
+type DeferReport struct {
+	PlainNanos        int64
+	DeferNanos        int64
+	PanicRecoverNanos int64
+	// panic 之后成功 recover 的次数, 应该等于 iterations
+	Recovered int
+}
+
+// deferSink 累加每个函数的返回值, 防止编译器把调用优化掉
+var deferSink int
+
+// DeferCost 分别测量普通函数调用, 带一个 defer 的函数调用, panic 之后 recover 的函数调用每次的开销
+func DeferCost(iterations int) DeferReport {
+	var r DeferReport
+	if iterations <= 0 {
+		return r
+	}
+
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		deferSink += plainCall(i)
+	}
+	r.PlainNanos = time.Since(start).Nanoseconds() / int64(iterations)
+
+	start = time.Now()
+	for i := 0; i < iterations; i++ {
+		deferSink += deferCall(i)
+	}
+	r.DeferNanos = time.Since(start).Nanoseconds() / int64(iterations)
+
+	start = time.Now()
+	for i := 0; i < iterations; i++ {
+		if panicCall(i) {
+			r.Recovered++
+		}
+	}
+	r.PanicRecoverNanos = time.Since(start).Nanoseconds() / int64(iterations)
+
+	return r
+}
+
+//go:noinline
+func plainCall(i int) int {
+	return done(i)
+}
+
+//go:noinline
+func deferCall(i int) (n int) {
+	defer func() { n = done(n) }()
+	return i
+}
+
+//go:noinline
+func panicCall(i int) (recovered bool) {
+	defer func() {
+		if recover() != nil {
+			recovered = true
+		}
+	}()
+	panic(i)
+}
+
+//go:noinline
+func done(i int) int {
+	return i + 1
+}
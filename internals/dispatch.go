@@ -0,0 +1,80 @@
+package internals
+
+import (
+	"reflect"
+	"time"
+)
+
+/*
+
+通过接口调用方法的时候, 接口值里的 itab 缓存了具体类型的方法表, 调用就是从 itab.fun 里取出函数指针再间接调用,
+比直接调用多一次内存读取, 而且没法内联. 编译器能确定接口里的具体类型的时候会去虚拟化(devirtualize)成直接调用.
+reflect.Value.Call 要把参数打包成 []Value, 检查类型, 再通过 reflectcall 调用, 比前两种慢一个数量级以上
+
+*/
+
+// DispatchReport 是 DispatchCost 的结果, 都是每次调用的纳秒数
+type DispatchReport struct {
+	ConcreteNanos  int64
+	InterfaceNanos int64
+	ReflectNanos   int64
+}
+
+type adder interface {
+	add(n int) int
+}
+
+type counter struct {
+	base int
+}
+
+//go:noinline
+func (c *counter) add(n int) int {
+	return c.base + n
+}
+
+// Add 是给 reflect 调用的导出方法, reflect 只能调用导出的方法
+//
+//go:noinline
+func (c *counter) Add(n int) int {
+	return c.base + n
+}
+
+// dispatchSink 累加每次调用的结果, 防止编译器把调用优化掉
+var dispatchSink int
+
+// dispatchTarget 放在包级变量里, 编译器看不出接口里的具体类型, 没法去虚拟化
+var dispatchTarget adder = &counter{base: 1}
+
+// DispatchCost 分别测量直接调用, 通过接口调用, 通过 reflect.Value.Call 调用同一个方法每次的开销
+func DispatchCost(iterations int) DispatchReport {
+	var r DispatchReport
+	if iterations <= 0 {
+		return r
+	}
+
+	c := &counter{base: 1}
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		dispatchSink += c.add(i)
+	}
+	r.ConcreteNanos = time.Since(start).Nanoseconds() / int64(iterations)
+
+	a := dispatchTarget
+	start = time.Now()
+	for i := 0; i < iterations; i++ {
+		dispatchSink += a.add(i)
+	}
+	r.InterfaceNanos = time.Since(start).Nanoseconds() / int64(iterations)
+
+	m := reflect.ValueOf(c).MethodByName("Add")
+	args := make([]reflect.Value, 1)
+	start = time.Now()
+	for i := 0; i < iterations; i++ {
+		args[0] = reflect.ValueOf(i)
+		dispatchSink += int(m.Call(args)[0].Int())
+	}
+	r.ReflectNanos = time.Since(start).Nanoseconds() / int64(iterations)
+
+	return r
+}
@@ -0,0 +1,10 @@
+package internals
+
+import "testing"
+
+func TestDispatchCostReflectSlowest(t *testing.T) {
+	r := DispatchCost(100000)
+	if r.ReflectNanos <= r.ConcreteNanos || r.ReflectNanos <= r.InterfaceNanos {
+		t.Errorf("%+v, want reflect to be the slowest", r)
+	}
+}
@@ -0,0 +1,67 @@
+package internals
+
+import "runtime"
+
+/*
+
+编译器的逃逸分析决定变量分配在栈上还是堆上, 返回局部变量的指针, 赋值给包级变量, 传给 interface 参数等都会让变量逃逸到堆上.
+可以用 go build -gcflags=-m 看编译器的判断, 这里用 runtime.MemStats.Mallocs 的增量从运行结果上验证.
+如果函数被内联到调用方, 调用方可能把结果留在栈上, 所以两个函数都加了 //go:noinline
+
+*/
+
+// escapeRuns 是每种情况重复的次数, 足够大才能和 ReadMemStats 自己的分配区分开
+const escapeRuns = 100000
+
+// EscapeReport 是 EscapeDemo 的结果
+type EscapeReport struct {
+	StackCaseMallocs uint64
+	HeapCaseMallocs  uint64
+}
+
+type point struct {
+	x, y int
+}
+
+// escapeSink 接收结果, 防止调用被优化掉
+var escapeSink int
+
+// stackCase 返回值是拷贝出去的, p 不会逃逸, 分配在栈上
+//
+//go:noinline
+func stackCase(i int) point {
+	p := point{x: i, y: i}
+	return p
+}
+
+// heapCase 返回了局部变量的指针, p 逃逸到堆上, 每次调用都要 newobject
+//
+//go:noinline
+func heapCase(i int) *point {
+	p := point{x: i, y: i}
+	return &p
+}
+
+// EscapeDemo 分别调用 stackCase 和 heapCase escapeRuns 次, 比较 Mallocs 的增量
+func EscapeDemo() EscapeReport {
+	var r EscapeReport
+	r.StackCaseMallocs = countMallocs(func() {
+		for i := 0; i < escapeRuns; i++ {
+			escapeSink += stackCase(i).x
+		}
+	})
+	r.HeapCaseMallocs = countMallocs(func() {
+		for i := 0; i < escapeRuns; i++ {
+			escapeSink += heapCase(i).x
+		}
+	})
+	return r
+}
+
+func countMallocs(fn func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	fn()
+	runtime.ReadMemStats(&after)
+	return after.Mallocs - before.Mallocs
+}
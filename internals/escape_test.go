@@ -0,0 +1,14 @@
+package internals
+
+import "testing"
+
+func TestEscapeDemo(t *testing.T) {
+	r := EscapeDemo()
+	// heapCase 每次调用都要分配, stackCase 一次都不用, 允许有少量别的分配混进来
+	if r.HeapCaseMallocs < escapeRuns*9/10 {
+		t.Errorf("HeapCaseMallocs = %d, want about %d", r.HeapCaseMallocs, escapeRuns)
+	}
+	if r.StackCaseMallocs > escapeRuns/100 {
+		t.Errorf("StackCaseMallocs = %d, want close to 0", r.StackCaseMallocs)
+	}
+}
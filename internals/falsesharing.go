@@ -0,0 +1,61 @@
+package internals
+
+import (
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+)
+
+/*
+
+CPU 缓存是以 cache line(x86 上是64字节)为单位同步的. 多个核各自写同一个 cache line 里不同的变量,
+虽然逻辑上没有共享, 但每次写都会让其他核上的这一行失效, 只能在核之间来回传, 这就是伪共享(false sharing).
+runtime 里很多结构(比如 p, mheap 的 central)都用 cpu.CacheLinePad 填充来避免这个问题
+
+*/
+
+const cacheLine = 64
+
+// FalseSharingReport 是 FalseSharing 的结果
+type FalseSharingReport struct {
+	PackedNanos int64
+	PaddedNanos int64
+}
+
+// paddedCounter 占满一个 cache line, 相邻的两个计数器不会落在同一行里
+type paddedCounter struct {
+	n int64
+	_ [cacheLine - 8]byte
+}
+
+var _ [cacheLine - unsafe.Sizeof(paddedCounter{})]struct{} // paddedCounter 必须正好是64字节
+var _ [unsafe.Sizeof(paddedCounter{}) - cacheLine]struct{}
+
+// FalseSharing 让 workers 个G各自累加自己的计数器 iterations 次, 计数器分别是紧挨着放的 int64 和填充到64字节的结构体
+//
+// 期间 GOMAXPROCS 设为 runtime.NumCPU(). 多核的时候 padded 应该明显更快, 单核的时候两者差不多.
+// workers <= 0 或者 iterations <= 0 返回空的结果
+func FalseSharing(workers int, iterations int) FalseSharingReport {
+	if workers <= 0 || iterations <= 0 {
+		return FalseSharingReport{}
+	}
+	prev := runtime.GOMAXPROCS(runtime.NumCPU())
+	defer runtime.GOMAXPROCS(prev)
+
+	packed := make([]int64, workers)
+	padded := make([]paddedCounter, workers)
+
+	return FalseSharingReport{
+		PackedNanos: timeWorkers(workers, func(i int) {
+			// 用原子操作保证每次都真的写内存, 不会被优化成寄存器里的累加
+			for j := 0; j < iterations; j++ {
+				atomic.AddInt64(&packed[i], 1)
+			}
+		}),
+		PaddedNanos: timeWorkers(workers, func(i int) {
+			for j := 0; j < iterations; j++ {
+				atomic.AddInt64(&padded[i].n, 1)
+			}
+		}),
+	}
+}
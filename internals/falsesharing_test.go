@@ -0,0 +1,31 @@
+package internals
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/chidaren/read-go-source-code/schedule"
+)
+
+func TestPaddedCounterSize(t *testing.T) {
+	if s := unsafe.Sizeof(paddedCounter{}); s != cacheLine {
+		t.Errorf("sizeof(paddedCounter) = %d, want %d", s, cacheLine)
+	}
+}
+
+func TestFalseSharing(t *testing.T) {
+	schedule.LeakCheck(t)
+	r := FalseSharing(4, 200000)
+	// 多核上 padded 应该明显更快, 单核上没有缓存行争用, 两者差不多, 所以只要求不慢太多
+	if float64(r.PaddedNanos) > 1.5*float64(r.PackedNanos) {
+		t.Errorf("%+v, padded is slower than packed", r)
+	}
+}
+
+func TestFalseSharingBadArguments(t *testing.T) {
+	for _, args := range [][2]int{{-1, 10}, {0, 10}, {4, -1}} {
+		if r := FalseSharing(args[0], args[1]); r != (FalseSharingReport{}) {
+			t.Errorf("FalseSharing(%d, %d) = %+v, want an empty report", args[0], args[1], r)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package internals
+
+import (
+	"runtime"
+	"time"
+)
+
+/*
+
+runtime.SetFinalizer 给对象注册一个终结器. GC 发现对象不可达的时候不会马上回收, 而是把终结器放到 finq 队列里,
+由专门的 fing 这个G依次调用, 对象在下一次 GC 才真正被回收. 所以终结器什么时候运行, 按什么顺序运行都没有保证,
+程序退出的时候还没运行的终结器也不会再运行
+
+*/
+
+// finalizerTimeout 是 FinalizerDemo 等终结器运行的最长时间
+const finalizerTimeout = 2 * time.Second
+
+// FinalizerReport 是 FinalizerDemo 的结果
+type FinalizerReport struct {
+	// 运行了的终结器的个数
+	RanCount int
+	// 终结器运行的顺序(对象创建时的编号), 一般和创建顺序不一样
+	Order []int
+	// 是否所有终结器都在 finalizerTimeout 内运行完了
+	CompletedWithinTimeout bool
+}
+
+// finalized 要大于16字节, 小于16字节并且没有指针的对象会被 tiny allocator 合并分配, 终结器可能永远不会运行
+type finalized struct {
+	id  int
+	pad [32]byte
+}
+
+// FinalizerDemo 分配 n 个带终结器的对象, 丢掉引用之后 GC 两次, 最多等 finalizerTimeout, 记录终结器运行的顺序
+//
+// 超时的时候返回 CompletedWithinTimeout=false, 不会一直等下去
+func FinalizerDemo(n int) FinalizerReport {
+	r := FinalizerReport{CompletedWithinTimeout: true}
+	if n <= 0 {
+		return r
+	}
+
+	ran := make(chan int, n)
+	allocFinalized(n, ran)
+
+	runtime.GC()
+	runtime.GC()
+
+	timeout := time.After(finalizerTimeout)
+	for r.RanCount < n {
+		select {
+		case id := <-ran:
+			r.Order = append(r.Order, id)
+			r.RanCount++
+		case <-timeout:
+			r.CompletedWithinTimeout = false
+			return r
+		}
+	}
+	return r
+}
+
+// allocFinalized 单独一个函数分配对象, 返回之后就没有任何引用了
+//
+//go:noinline
+func allocFinalized(n int, ran chan<- int) {
+	for i := 0; i < n; i++ {
+		f := &finalized{id: i}
+		runtime.SetFinalizer(f, func(f *finalized) { ran <- f.id })
+	}
+}
@@ -0,0 +1,23 @@
+package internals
+
+import (
+	"testing"
+
+	"github.com/chidaren/read-go-source-code/schedule"
+)
+
+func TestFinalizerDemo(t *testing.T) {
+	schedule.LeakCheck(t)
+	r := FinalizerDemo(0)
+	if r.RanCount != 0 || !r.CompletedWithinTimeout {
+		t.Errorf("n=0: %+v, want RanCount 0 and completed", r)
+	}
+
+	r = FinalizerDemo(10)
+	if r.CompletedWithinTimeout && (r.RanCount != 10 || len(r.Order) != 10) {
+		t.Errorf("n=10: %+v, completed but not every finalizer ran", r)
+	}
+	if r.RanCount > 10 {
+		t.Errorf("n=10: RanCount = %d", r.RanCount)
+	}
+}
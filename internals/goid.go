@@ -0,0 +1,71 @@
+package internals
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+/*
+
+runtime 故意不提供获取 goroutine id 的 API, 避免大家用它做 goroutine local storage. 唯一的办法是解析 runtime.Stack 的第一行:
+
+	goroutine 42 [running]:
+	goroutine 42 gp=0xc000002380 m=0 mp=0x5a2e80 [running]:   (GOTRACEBACK=system 之类的时候)
+
+goid 是从 sched.goidgen 里分配的, 每个P一次批量拿16个(_GoidCacheBatch)缓存起来, 所以同时创建的G的 id 不一定连续.
+G退出之后 g 结构体会放回 gFree 被复用, 但是复用的时候会分配新的 goid, id 本身不会重复
+
+*/
+
+// GoroutineID 返回当前G的id, 只用于学习, 不要在生产代码里依赖它
+func GoroutineID() (uint64, error) {
+	var buf [64]byte
+	return parseGoroutineID(buf[:runtime.Stack(buf[:], false)])
+}
+
+// parseGoroutineID 从 "goroutine 42 ..." 里取出 42
+func parseGoroutineID(b []byte) (uint64, error) {
+	rest := bytes.TrimPrefix(b, []byte("goroutine "))
+	if len(rest) == len(b) {
+		return 0, fmt.Errorf("internals: unexpected stack header %q", firstLine(b))
+	}
+	end := 0
+	for end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return 0, fmt.Errorf("internals: no goroutine id in %q", firstLine(b))
+	}
+	return strconv.ParseUint(string(rest[:end]), 10, 64)
+}
+
+func firstLine(b []byte) []byte {
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		return b[:i]
+	}
+	return b
+}
+
+// CurrentGs 同时启动 n 个G, 返回它们各自的 id(按启动顺序)
+//
+// 都是在当前P上创建的, 所以一般是连续的, 中间跳过的是别的G用掉的. 多调用几次可以看到 id 一直在增长, 不会复用. n <= 0 返回 nil
+func CurrentGs(n int) []uint64 {
+	if n <= 0 {
+		return nil
+	}
+	ids := make([]uint64, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			// runtime.Stack 的格式在所有版本里都以 "goroutine N" 开头, 这里不会出错
+			ids[i], _ = GoroutineID()
+		}(i)
+	}
+	wg.Wait()
+	return ids
+}
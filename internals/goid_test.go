@@ -0,0 +1,65 @@
+package internals
+
+import (
+	"testing"
+
+	"github.com/chidaren/read-go-source-code/schedule"
+)
+
+func TestGoroutineIDStable(t *testing.T) {
+	schedule.LeakCheck(t)
+	a, err := GoroutineID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := GoroutineID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Errorf("two calls on the same goroutine returned %d and %d", a, b)
+	}
+
+	other := make(chan uint64)
+	go func() {
+		id, _ := GoroutineID()
+		other <- id
+	}()
+	if id := <-other; id == a {
+		t.Errorf("another goroutine has the same id %d", id)
+	}
+}
+
+func TestParseGoroutineID(t *testing.T) {
+	for in, want := range map[string]uint64{
+		"goroutine 42 [running]:\nmain.main()":                        42,
+		"goroutine 7 gp=0xc000002380 m=0 mp=0x5a2e80 [running]:\n...": 7,
+	} {
+		got, err := parseGoroutineID([]byte(in))
+		if err != nil || got != want {
+			t.Errorf("parseGoroutineID(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "goroutine", "goroutine x [running]:", "main.main()"} {
+		if id, err := parseGoroutineID([]byte(in)); err == nil {
+			t.Errorf("parseGoroutineID(%q) = %d, want an error", in, id)
+		}
+	}
+}
+
+func TestCurrentGs(t *testing.T) {
+	schedule.LeakCheck(t)
+	ids := CurrentGs(10)
+	seen := make(map[uint64]bool)
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			t.Fatalf("ids = %v, want 10 distinct non-zero ids", ids)
+		}
+		seen[id] = true
+	}
+	for _, n := range []int{0, -1} {
+		if ids := CurrentGs(n); len(ids) != 0 {
+			t.Errorf("CurrentGs(%d) = %v, want none", n, ids)
+		}
+	}
+}
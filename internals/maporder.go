@@ -0,0 +1,34 @@
+package internals
+
+/*
+
+range 一个 map 的时候 mapiterinit 会用随机数决定从哪个 bucket, bucket 里的哪个位置开始遍历(go1.24 的 swiss map 也是随机选起点),
+所以同一个 map 每次 range 的顺序都可能不一样, 这是故意的, 防止有人依赖遍历顺序
+
+*/
+
+// MapOrderRandomized 往 map 里放 keys 个元素, range trials 次, 每次记录第一个遍历到的 key, 返回第一个 key 是否变化过
+//
+// 只有一个元素(或者没有)的 map 顺序不可能变化, 直接返回 false. 元素越多, trials 越大, 越容易观察到随机化
+func MapOrderRandomized(keys, trials int) bool {
+	if keys < 2 {
+		return false
+	}
+
+	m := make(map[int]struct{}, keys)
+	for i := 0; i < keys; i++ {
+		m[i] = struct{}{}
+	}
+
+	first := -1
+	for t := 0; t < trials; t++ {
+		for k := range m {
+			if first >= 0 && k != first {
+				return true
+			}
+			first = k
+			break
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+package internals
+
+import "testing"
+
+func TestMapOrderRandomized(t *testing.T) {
+	// 1000个元素 range 100次, 第一个 key 每次都一样的概率可以忽略
+	if !MapOrderRandomized(1000, 100) {
+		t.Error("map iteration order never changed")
+	}
+	for _, keys := range []int{0, 1} {
+		if MapOrderRandomized(keys, 100) {
+			t.Errorf("keys=%d reported randomized order", keys)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package internals
+
+import (
+	"runtime"
+	"sync"
+)
+
+/*
+
+sync.Pool 每个P有一个 poolLocal: private 只能被当前P使用, shared 是一个无锁的双端队列, 其他P可以从尾部偷.
+每次 GC 开始的时候 poolCleanup 把 local 挪到 victim, 把原来的 victim 丢掉, 所以放进去的对象经过一次 GC 还能从 victim 里拿到,
+经过两次 GC 就没了, 之后的 Get 只能调用 New
+
+*/
+
+// PoolReport 是 PoolLifecycle 的结果
+type PoolReport struct {
+	// 没有 GC 的时候, Get 拿到之前 Put 进去的对象的次数
+	ReusedBeforeGC int
+	// 两次 runtime.GC() 之后, Get 拿到之前 Put 进去的对象的次数
+	ReusedAfterGC int
+	// New 被调用的总次数
+	NewCalls int
+	// 是否 LockOSThread 了
+	Pinned bool
+}
+
+type pooled struct {
+	fresh bool
+}
+
+// PoolLifecycle 往 sync.Pool 里 Put allocs 个对象, 然后 Get 回来数一下有多少是复用的, 再 Put 一遍, GC 两次之后再数一次
+//
+// 整个过程都在一个 LockOSThread 的G里跑, 尽量一直在同一个P上, 这样 Put 和 Get 用的是同一个 poolLocal.
+// 即使这样G也可能被抢占之后换到别的P上, 那时候只能从别的P的 shared 偷, 来不及偷到的就会调用 New. allocs < 0 当成0
+func PoolLifecycle(allocs int) PoolReport {
+	// 在新的G里 panic 调用方没法 recover, 要在启动之前检查
+	if allocs < 0 {
+		allocs = 0
+	}
+	ch := make(chan PoolReport)
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		r := PoolReport{Pinned: true}
+		p := sync.Pool{New: func() interface{} {
+			r.NewCalls++
+			return &pooled{fresh: true}
+		}}
+
+		put := func() {
+			objs := make([]*pooled, allocs)
+			for i := range objs {
+				objs[i] = &pooled{}
+			}
+			for _, o := range objs {
+				p.Put(o)
+			}
+		}
+		get := func() (reused int) {
+			for i := 0; i < allocs; i++ {
+				if o := p.Get().(*pooled); !o.fresh {
+					reused++
+				}
+			}
+			return reused
+		}
+
+		put()
+		r.ReusedBeforeGC = get()
+
+		put()
+		// 第一次 GC 挪到 victim, 第二次才真正丢掉
+		runtime.GC()
+		runtime.GC()
+		r.ReusedAfterGC = get()
+
+		ch <- r
+	}()
+	return <-ch
+}
@@ -0,0 +1,28 @@
+package internals
+
+import (
+	"testing"
+
+	"github.com/chidaren/read-go-source-code/schedule"
+)
+
+func TestPoolLifecycle(t *testing.T) {
+	schedule.LeakCheck(t)
+	r := PoolLifecycle(100)
+	if !r.Pinned {
+		t.Error("Pinned = false")
+	}
+	// 两次 GC 之后 victim 也被丢掉了, 第二轮的 Get 都要调用 New
+	if r.ReusedAfterGC != 0 {
+		t.Errorf("ReusedAfterGC = %d, want 0", r.ReusedAfterGC)
+	}
+	if r.ReusedBeforeGC+r.NewCalls < 100 {
+		t.Errorf("%+v, first round got fewer than 100 objects", r)
+	}
+}
+
+func TestPoolLifecycleNegative(t *testing.T) {
+	if r := PoolLifecycle(-1); r.ReusedBeforeGC != 0 || r.ReusedAfterGC != 0 || r.NewCalls != 0 {
+		t.Errorf("PoolLifecycle(-1) = %+v, want an empty report", r)
+	}
+}
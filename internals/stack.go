@@ -0,0 +1,77 @@
+package internals
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+/*
+
+G的栈一开始只有2KB(go1.19 之后会根据之前G的平均栈大小调整初始大小), 函数头部检查 stackguard 发现不够用的时候调用 morestack,
+newstack 分配一块两倍大的栈, copystack 把旧栈整个拷过去并调整栈上的指针, 所以栈扩容之后同一个局部变量的地址会变.
+morestack 也是协作式抢占的入口: sysmon 要抢占一个G的时候会把 stackguard 设成 stackPreempt, 下一次函数调用就会进到 newstack 里让出P
+
+*/
+
+// MaxStackDepth 是 StackGrowth 允许的最大递归深度, 每层大概1KB, 离默认的最大栈(64位是1GB)还很远
+const MaxStackDepth = 100000
+
+// StackReport 是 StackGrowth 的结果
+type StackReport struct {
+	// 栈被拷贝(扩容)的次数
+	GrowthEvents int
+	// 实际递归到的深度
+	MaxDepthReached int
+	// 最深的时候栈用了多少字节
+	MaxStackBytes uintptr
+}
+
+// StackGrowth 在一个新的G里递归 depth 层, 每层有一个1KB的局部数组, 通过栈上变量地址的变化统计栈扩容的次数
+//
+// depth 超过 MaxStackDepth 返回错误, 不会真的去递归, 避免把栈撑爆(栈溢出是 fatal, recover 不了)
+func StackGrowth(depth int) (StackReport, error) {
+	if depth < 0 || depth > MaxStackDepth {
+		return StackReport{}, fmt.Errorf("internals: stack depth %d out of range [0, %d]", depth, MaxStackDepth)
+	}
+
+	ch := make(chan StackReport)
+	go func() {
+		var r StackReport
+		// root 在最外层的栈帧上, 栈被拷贝之后它的地址会跟着变, 传下去的指针也会被 copystack 调整
+		var root byte
+		s := stackState{root: &root, last: uintptr(unsafe.Pointer(&root)), report: &r}
+		s.recurse(depth)
+		ch <- r
+	}()
+	return <-ch, nil
+}
+
+type stackState struct {
+	root   *byte
+	last   uintptr
+	report *StackReport
+}
+
+//go:noinline
+func (s *stackState) recurse(n int) byte {
+	var frame [1024]byte
+	frame[n%len(frame)] = byte(n)
+
+	// 每一层都检查一次, 检查的是当前真实的地址
+	if p := uintptr(unsafe.Pointer(s.root)); p != s.last {
+		s.report.GrowthEvents++
+		s.last = p
+	}
+	if used := uintptr(unsafe.Pointer(s.root)) - uintptr(unsafe.Pointer(&frame[0])); used > s.report.MaxStackBytes {
+		s.report.MaxStackBytes = used
+	}
+
+	if n == 0 {
+		return frame[0]
+	}
+	depth := s.recurse(n-1) + frame[n%len(frame)]
+	if s.report.MaxDepthReached < n {
+		s.report.MaxDepthReached = n
+	}
+	return depth
+}
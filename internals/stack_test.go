@@ -0,0 +1,33 @@
+package internals
+
+import (
+	"testing"
+
+	"github.com/chidaren/read-go-source-code/schedule"
+)
+
+func TestStackGrowth(t *testing.T) {
+	schedule.LeakCheck(t)
+	// 每层1KB, 1000层远远超过初始的 2KB 栈
+	r, err := StackGrowth(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.GrowthEvents < 1 {
+		t.Errorf("GrowthEvents = %d, want >= 1", r.GrowthEvents)
+	}
+	if r.MaxDepthReached != 1000 {
+		t.Errorf("MaxDepthReached = %d, want 1000", r.MaxDepthReached)
+	}
+}
+
+func TestStackGrowthDepthLimit(t *testing.T) {
+	for _, depth := range []int{-1, MaxStackDepth + 1} {
+		if _, err := StackGrowth(depth); err == nil {
+			t.Errorf("StackGrowth(%d) succeeded, want an error", depth)
+		}
+	}
+	if _, err := StackGrowth(0); err != nil {
+		t.Errorf("StackGrowth(0): %v", err)
+	}
+}
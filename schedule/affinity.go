@@ -0,0 +1,74 @@
+package schedule
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// AffinityThroughput 启动 workers 个 LockOSThread 的G, 每个G把自己的线程绑定到 cpuSet 里的CPU上, 空转 d, 返回每个G的循环次数
+//
+// GOMAXPROCS 限制的是同时运行Go代码的M的数量, 不管这些M能用几个CPU. 把线程限制在少数几个CPU上之后,
+// 即使P够多, 这些M也只能在这几个CPU上轮流跑, 总的吞吐量受 len(cpuSet) 限制.
+// 每个线程在 UnlockOSThread 之前都会恢复原来的亲和性, 恢复失败的线程不会解绑而是随着G退出被销毁, 不会把限制带给之后复用这个线程的G. 目前只支持 linux, 其他平台返回 ErrUnsupported
+func AffinityThroughput(cpuSet []int, workers int, d time.Duration) ([]int64, error) {
+	if !affinitySupported {
+		return nil, ErrUnsupported
+	}
+	if len(cpuSet) == 0 {
+		return nil, fmt.Errorf("schedule: empty cpuSet")
+	}
+	if err := validateMin("workers", workers, 1); err != nil {
+		return nil, err
+	}
+	if d <= 0 {
+		return nil, errDuration
+	}
+	n := runtime.NumCPU()
+	for _, cpu := range cpuSet {
+		if cpu < 0 || cpu >= n {
+			return nil, fmt.Errorf("schedule: cpu %d out of range [0, %d)", cpu, n)
+		}
+	}
+
+	var (
+		loops = make([]int64, workers)
+		errs  = make([]error, workers)
+		wg    sync.WaitGroup
+		gate  StartGate
+	)
+	wg.Add(workers)
+	gate.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func(i int) {
+			defer wg.Done()
+			runtime.LockOSThread()
+
+			restore, err := setThreadAffinity(cpuSet)
+			// 出错也要报到, 不然 Go() 会一直等
+			gate.Ready()
+			if err != nil {
+				runtime.UnlockOSThread()
+				errs[i] = err
+				return
+			}
+			loops[i] = spinFor(d)
+			if err := restore(); err != nil {
+				// 恢复失败就不解绑, G退出的时候 runtime 会直接销毁这个线程, 不会被别的G复用
+				errs[i] = err
+				return
+			}
+			runtime.UnlockOSThread()
+		}(i)
+	}
+	gate.Go()
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return loops, nil
+}
@@ -0,0 +1,23 @@
+package schedule
+
+import "golang.org/x/sys/unix"
+
+const affinitySupported = true
+
+// setThreadAffinity 把当前线程绑定到 cpus 上, 返回恢复原来亲和性的函数, 调用方必须已经 LockOSThread
+func setThreadAffinity(cpus []int) (restore func() error, err error) {
+	var orig unix.CPUSet
+	// pid 为0表示当前线程
+	if err := unix.SchedGetaffinity(0, &orig); err != nil {
+		return nil, err
+	}
+
+	var set unix.CPUSet
+	for _, cpu := range cpus {
+		set.Set(cpu)
+	}
+	if err := unix.SchedSetaffinity(0, &set); err != nil {
+		return nil, err
+	}
+	return func() error { return unix.SchedSetaffinity(0, &orig) }, nil
+}
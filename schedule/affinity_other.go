@@ -0,0 +1,9 @@
+//go:build !linux
+
+package schedule
+
+const affinitySupported = false
+
+func setThreadAffinity(cpus []int) (restore func() error, err error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,86 @@
+package schedule
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// backpressureCapacity 是 BackpressureFix 用的 channel 容量
+const backpressureCapacity = 4
+
+// BackpressureReport 是 BackpressureFix 的结果
+type BackpressureReport struct {
+	// 消费者收到的次数
+	ConsumerRuns int64 `json:"consumer_runs"`
+	// 生产者是不是因为 channel 满了阻塞过
+	ProducerBlocked bool `json:"producer_blocked"`
+}
+
+// BackpressureFix 和 StarvationDemo 对照: 生产者一样是一直在干活不主动让出, 区别是每次都要往有界的 channel 里发数据
+//
+// channel 满了之后 chansend 会 gopark 生产者, 让出P, 消费者就有机会运行, 所以即使 GOMAXPROCS(1) 消费者也不会被饿死.
+// 也就是说 channel 操作本身就是一个调度点, 容量为0的时候每次发送都要等消费者来接, 效果一样
+func BackpressureFix(procs int, d time.Duration) (BackpressureReport, error) {
+	return backpressure(procs, d, backpressureCapacity)
+}
+
+func backpressure(procs int, d time.Duration, capacity int) (BackpressureReport, error) {
+	var r BackpressureReport
+
+	opts := Options{Procs: procs, Duration: d}
+	if err := opts.Validate(); err != nil && !IsWarning(err) {
+		return r, err
+	}
+	prev := runtime.GOMAXPROCS(opts.Procs)
+	defer runtime.GOMAXPROCS(prev)
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Duration)
+	defer cancel()
+
+	var (
+		ch      = make(chan int64, capacity)
+		runs    int64
+		blocked int32
+		wg      sync.WaitGroup
+	)
+	wg.Add(2)
+
+	// 生产者, 除了发送之外没有别的调度点
+	go func() {
+		defer wg.Done()
+		defer close(ch)
+		for v := int64(0); ; v++ {
+			// 消费者跟得上的时候 channel 一直不满, 这里也要看 ctx, 否则到期了也不会退出
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- v:
+				continue
+			default:
+			}
+			// 发不进去, 接下来的发送会阻塞
+			atomic.StoreInt32(&blocked, 1)
+			select {
+			case ch <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// 消费者
+	go func() {
+		defer wg.Done()
+		for range ch {
+			atomic.AddInt64(&runs, 1)
+		}
+	}()
+
+	wg.Wait()
+	r.ConsumerRuns = atomic.LoadInt64(&runs)
+	r.ProducerBlocked = atomic.LoadInt32(&blocked) == 1
+	return r, nil
+}
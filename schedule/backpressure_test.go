@@ -0,0 +1,42 @@
+package schedule
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackpressure(t *testing.T) {
+	LeakCheck(t)
+	const d = 100 * time.Millisecond
+	for _, capacity := range []int{0, 1, backpressureCapacity} {
+		t.Run(fmt.Sprintf("cap=%d", capacity), func(t *testing.T) {
+			start := time.Now()
+			r, err := backpressure(1, d, capacity)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if elapsed := time.Since(start); elapsed > 10*d {
+				t.Errorf("returned after %v, want about %v", elapsed, d)
+			}
+			// GOMAXPROCS(1) 下消费者只有在生产者被 channel 阻塞的时候才能运行
+			if r.ConsumerRuns == 0 {
+				t.Error("ConsumerRuns = 0 under GOMAXPROCS(1)")
+			}
+			if !r.ProducerBlocked {
+				t.Error("producer never blocked on the bounded channel")
+			}
+		})
+	}
+}
+
+func TestBackpressureFix(t *testing.T) {
+	LeakCheck(t)
+	r, err := BackpressureFix(1, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ConsumerRuns == 0 {
+		t.Error("ConsumerRuns = 0")
+	}
+}
@@ -0,0 +1,32 @@
+package schedule
+
+import (
+	"bytes"
+	"runtime"
+	"runtime/pprof"
+)
+
+// WithBlockProfile 以 rate 开启阻塞分析(见 runtime.SetBlockProfileRate)执行 fn, 返回 block profile 和它的 protobuf 数据
+//
+// 可以用来确认G到底阻塞在哪里, 比如 ContentionCompare 里 channel 和 Mutex 的等待.
+// rate <= 0 的时候关闭阻塞分析, 照样执行 fn, 返回 nil, nil, nil. 序列化 profile 失败的时候返回 profile 和错误.
+// runtime 没有提供读取当前 rate 的方法, 所以结束后恢复成默认的 0(关闭)
+func WithBlockProfile(rate int, fn func()) (*pprof.Profile, []byte, error) {
+	if rate <= 0 {
+		runtime.SetBlockProfileRate(0)
+		fn()
+		return nil, nil, nil
+	}
+
+	runtime.SetBlockProfileRate(rate)
+	defer runtime.SetBlockProfileRate(0)
+
+	fn()
+
+	p := pprof.Lookup("block")
+	var buf bytes.Buffer
+	if err := p.WriteTo(&buf, 0); err != nil {
+		return p, nil, err
+	}
+	return p, buf.Bytes(), nil
+}
@@ -0,0 +1,39 @@
+package schedule
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/pprof/profile"
+)
+
+func TestWithBlockProfile(t *testing.T) {
+	LeakCheck(t)
+	p, b, err := WithBlockProfile(1, func() { ContentionCompare(4, 1000) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil || p.Name() != "block" {
+		t.Fatalf("profile = %v, want the block profile", p)
+	}
+	if len(b) == 0 {
+		t.Fatal("empty profile bytes")
+	}
+	prof, err := profile.Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("profile.Parse: %v", err)
+	}
+	if len(prof.Sample) == 0 {
+		t.Error("block profile has no samples after channel/mutex contention")
+	}
+}
+
+func TestWithBlockProfileDisabled(t *testing.T) {
+	for _, rate := range []int{0, -1} {
+		ran := false
+		p, b, err := WithBlockProfile(rate, func() { ran = true })
+		if !ran || p != nil || len(b) != 0 || err != nil {
+			t.Errorf("rate=%d: ran=%v profile=%v bytes=%d err=%v, want fn run and nothing returned", rate, ran, p, len(b), err)
+		}
+	}
+}
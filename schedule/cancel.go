@@ -0,0 +1,81 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// cancelTimeout 是 CancelPropagation 等所有G退出的最长时间
+const cancelTimeout = 5 * time.Second
+
+// CancelReport 是 CancelPropagation 的结果
+type CancelReport struct {
+	Depth int `json:"depth"`
+	// 从取消根 context 到最后一个G观察到 Done 的时间
+	PropagationNanos int64 `json:"propagation_nanos"`
+	// 是否所有G都在 cancelTimeout 之内观察到了取消
+	AllCancelled bool `json:"all_cancelled"`
+}
+
+// CancelPropagation 建一条 depth 层嵌套的 context.WithCancel 链, 每一层(包括根)都有一个G阻塞在 ctx.Done() 上,
+// 然后取消根 context, 测量所有G都被唤醒需要多久
+//
+// cancelCtx.cancel 会先关闭自己的 done channel(唤醒等在上面的G), 再递归取消所有子 context,
+// 被唤醒的G都是通过 goready 放到当前P的队列里的, 所以G越多, 最后一个G真正运行的时间越晚. depth == 0 的时候只有根, depth < 0 返回错误
+func CancelPropagation(depth int) (CancelReport, error) {
+	if err := validateMin("depth", depth, 0); err != nil {
+		return CancelReport{}, err
+	}
+	r := CancelReport{Depth: depth}
+
+	// 超时返回的时候用 quit 让还没退出的G也退出, 不会泄漏
+	quit := make(chan struct{})
+	defer close(quit)
+
+	root, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		gate StartGate
+	)
+	wait := func(ctx context.Context) {
+		wg.Add(1)
+		gate.Add(1)
+		go func() {
+			defer wg.Done()
+			gate.Ready()
+			select {
+			case <-ctx.Done():
+			case <-quit:
+			}
+		}()
+	}
+
+	wait(root)
+	ctx := root
+	for i := 0; i < depth; i++ {
+		var c context.CancelFunc
+		ctx, c = context.WithCancel(ctx)
+		defer c()
+		wait(ctx)
+	}
+	gate.Go()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	start := time.Now()
+	cancel()
+	select {
+	case <-done:
+		r.AllCancelled = true
+	case <-time.After(cancelTimeout):
+	}
+	r.PropagationNanos = time.Since(start).Nanoseconds()
+	return r, nil
+}
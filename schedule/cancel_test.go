@@ -0,0 +1,19 @@
+package schedule
+
+import "testing"
+
+func TestCancelPropagation(t *testing.T) {
+	LeakCheck(t)
+	for _, depth := range []int{0, 1, 100} {
+		r, err := CancelPropagation(depth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Depth != depth || !r.AllCancelled {
+			t.Errorf("depth=%d: %+v, want all goroutines cancelled", depth, r)
+		}
+		if r.PropagationNanos <= 0 {
+			t.Errorf("depth=%d: PropagationNanos = %d, want > 0", depth, r.PropagationNanos)
+		}
+	}
+}
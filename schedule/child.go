@@ -0,0 +1,87 @@
+package schedule
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+/*
+
+有些现象只能在子进程里观察, 比如 GODEBUG=schedtrace 只在进程启动的时候读取, 它的输出写到 stderr.
+子进程就是当前的可执行文件(go test 的时候就是测试二进制), 通过环境变量告诉它要跑哪个 workload,
+只要导入了本包, init 里就会拦截下来执行完 workload 之后直接退出, 不会跑到 main 或者测试函数
+
+*/
+
+const (
+	childEnv    = "SCHEDULE_CHILD"
+	childArgEnv = "SCHEDULE_CHILD_ARG"
+)
+
+// childWorkloads 必须是包级变量的初始化, 这样在 init 执行之前就已经准备好了
+var childWorkloads = map[string]func(arg string){
+	"placement":   placementChild,
+	"spinning":    spinningChild,
+	"unstealable": unstealableChild,
+}
+
+func init() {
+	name := os.Getenv(childEnv)
+	if name == "" {
+		return
+	}
+	if f, ok := childWorkloads[name]; ok {
+		f(os.Getenv(childArgEnv))
+		os.Exit(0)
+	}
+}
+
+// childCommand 构造运行 workload name 的子进程, godebug 里的设置会覆盖父进程 GODEBUG 里同名的设置, 其他的保留
+func childCommand(name, arg string, godebug ...string) (*exec.Cmd, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(exe)
+	env := make([]string, 0, len(os.Environ())+3)
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "GODEBUG=") || strings.HasPrefix(kv, childEnv+"=") || strings.HasPrefix(kv, childArgEnv+"=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	env = append(env,
+		childEnv+"="+name,
+		childArgEnv+"="+arg,
+		"GODEBUG="+mergeGODEBUG(os.Getenv("GODEBUG"), godebug...),
+	)
+	cmd.Env = env
+	return cmd, nil
+}
+
+// mergeGODEBUG 把 settings(key=value) 合并到 parent 里, 同名的 key 以 settings 为准
+func mergeGODEBUG(parent string, settings ...string) string {
+	override := make(map[string]bool, len(settings))
+	for _, s := range settings {
+		override[godebugKey(s)] = true
+	}
+
+	var out []string
+	for _, s := range strings.Split(parent, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" || override[godebugKey(s)] {
+			continue
+		}
+		out = append(out, s)
+	}
+	return strings.Join(append(out, settings...), ",")
+}
+
+func godebugKey(setting string) string {
+	if i := strings.IndexByte(setting, '='); i >= 0 {
+		return setting[:i]
+	}
+	return setting
+}
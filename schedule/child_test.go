@@ -0,0 +1,92 @@
+package schedule
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// childTimeout 是 runChild 等子进程的最长时间, 超时就杀掉
+const childTimeout = 30 * time.Second
+
+// testChildWorkloads 是只有测试才用的子进程 workload. child.go 的 init 不认识的名字会放过去, 在 TestMain 里处理
+var testChildWorkloads = map[string]func(arg string){
+	"test-starvation":      starvationTestChild,
+	"test-starvation-demo": starvationDemoTestChild,
+	"test-gosched":         goschedTestChild,
+	"test-syscall-threads": syscallThreadsTestChild,
+}
+
+func TestMain(m *testing.M) {
+	if f, ok := testChildWorkloads[os.Getenv(childEnv)]; ok {
+		f(os.Getenv(childArgEnv))
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runChild 在子进程里跑 workload name, 返回去掉首尾空白的 stdout
+func runChild(t *testing.T, name, arg string, godebug ...string) string {
+	t.Helper()
+	cmd, err := childCommand(name, arg, godebug...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	timer := time.AfterFunc(childTimeout, func() { cmd.Process.Kill() })
+	defer timer.Stop()
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("child %s(%s): %v\n%s", name, arg, err, stderr.Bytes())
+	}
+	return strings.TrimSpace(stdout.String())
+}
+
+func TestMergeGODEBUG(t *testing.T) {
+	tests := []struct {
+		parent   string
+		settings []string
+		want     string
+	}{
+		{"", nil, ""},
+		{"", []string{"schedtrace=1"}, "schedtrace=1"},
+		{"gctrace=1", []string{"schedtrace=1"}, "gctrace=1,schedtrace=1"},
+		// 同名的以 settings 为准, 其他的保留原来的顺序
+		{"schedtrace=1000,gctrace=1,madvdontneed=1", []string{"schedtrace=1"}, "gctrace=1,madvdontneed=1,schedtrace=1"},
+		{"asyncpreemptoff=0", []string{"asyncpreemptoff=1", "schedtrace=1"}, "asyncpreemptoff=1,schedtrace=1"},
+		// 空的和只有空白的项去掉, 其他项两边的空白也去掉
+		{" gctrace=1 ,, ,schedtrace=5", []string{"schedtrace=1"}, "gctrace=1,schedtrace=1"},
+		{",", nil, ""},
+		// 没有 = 的也按 key 处理
+		{"inittrace,gctrace=1", []string{"inittrace=1"}, "gctrace=1,inittrace=1"},
+	}
+	for _, tt := range tests {
+		if got := mergeGODEBUG(tt.parent, tt.settings...); got != tt.want {
+			t.Errorf("mergeGODEBUG(%q, %q) = %q, want %q", tt.parent, tt.settings, got, tt.want)
+		}
+	}
+}
+
+func TestChildCommandEnv(t *testing.T) {
+	t.Setenv("GODEBUG", "gctrace=1,schedtrace=1000")
+	t.Setenv(childEnv, "stale")
+	cmd, err := childCommand("placement", "300", "schedtrace=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, kv := range cmd.Env {
+		if strings.HasPrefix(kv, "GODEBUG=") || strings.HasPrefix(kv, childEnv+"=") || strings.HasPrefix(kv, childArgEnv+"=") {
+			got = append(got, kv)
+		}
+	}
+	want := []string{childEnv + "=placement", childArgEnv + "=300", "GODEBUG=gctrace=1,schedtrace=1"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("child env = %q, want %q", got, want)
+	}
+}
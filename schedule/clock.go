@@ -0,0 +1,11 @@
+package schedule
+
+import _ "unsafe" // go:linkname
+
+// nanotime 是 runtime 内部的单调时钟, 单位纳秒.
+//
+// runtime.nanotime 是 nosplit 的, 函数开头没有栈检查, 也就不会响应 sysmon 设置的抢占标记(stackguard0 = stackPreempt).
+// time.Now 不一样, 它是普通函数, 每次调用都是一个协作式抢占点, 所以死循环里想看时间又不想给调度器机会的时候用这个
+//
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
@@ -0,0 +1,96 @@
+package schedule
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// ContentionResult 是 ContentionCompare 的结果
+type ContentionResult struct {
+	ChanNanos  int64 `json:"chan_nanos"`
+	MutexNanos int64 `json:"mutex_nanos"`
+	// 每个 worker 抢到的次数的变异系数(标准差/均值), 0 表示完全平均
+	ChanFair  float64 `json:"chan_fair"`
+	MutexFair float64 `json:"mutex_fair"`
+	// 最终计数, 都应该等于 workers*ops
+	ChanCount  int64 `json:"chan_count"`
+	MutexCount int64 `json:"mutex_count"`
+}
+
+// ContentionCompare 让 workers 个G一起抢着把一个计数器加到 workers*ops, 分别用容量为1的 channel 和 sync.Mutex 保护
+//
+// 抢不到的G会 gopark 让出P, 区别是 channel 的等待队列是严格FIFO的, Mutex 在普通模式下新来的G更容易抢到(见 sync/sync.mutex.md).
+// workers < 1 或者 ops < 1 返回错误
+func ContentionCompare(workers int, ops int) (ContentionResult, error) {
+	if err := validateMin("workers", workers, 1); err != nil {
+		return ContentionResult{}, err
+	}
+	if err := validateMin("ops", ops, 1); err != nil {
+		return ContentionResult{}, err
+	}
+
+	total := int64(workers) * int64(ops)
+	var r ContentionResult
+
+	sem := make(chan struct{}, 1)
+	r.ChanNanos, r.ChanCount, r.ChanFair = contend(workers, total, func() { sem <- struct{}{} }, func() { <-sem })
+
+	var mu sync.Mutex
+	r.MutexNanos, r.MutexCount, r.MutexFair = contend(workers, total, mu.Lock, mu.Unlock)
+
+	return r, nil
+}
+
+// contend 跑一轮抢占计数器, 返回耗时, 最终计数和每个 worker 次数的变异系数
+func contend(workers int, total int64, lock, unlock func()) (nanos, count int64, cv float64) {
+	var (
+		perWorker = make([]int64, workers)
+		wg        sync.WaitGroup
+		gate      StartGate
+	)
+
+	wg.Add(workers)
+	gate.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func(i int) {
+			defer wg.Done()
+			gate.Ready()
+			for {
+				lock()
+				if count >= total {
+					unlock()
+					return
+				}
+				count++
+				unlock()
+				perWorker[i]++
+			}
+		}(i)
+	}
+
+	gate.Go()
+	start := time.Now()
+	wg.Wait()
+	return time.Since(start).Nanoseconds(), count, coefficientOfVariation(perWorker)
+}
+
+func coefficientOfVariation(counts []int64) float64 {
+	if len(counts) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, c := range counts {
+		sum += float64(c)
+	}
+	mean := sum / float64(len(counts))
+	if mean == 0 {
+		return 0
+	}
+	var sq float64
+	for _, c := range counts {
+		d := float64(c) - mean
+		sq += d * d
+	}
+	return math.Sqrt(sq/float64(len(counts))) / mean
+}
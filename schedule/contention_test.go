@@ -0,0 +1,21 @@
+package schedule
+
+import "testing"
+
+func TestContentionCompare(t *testing.T) {
+	LeakCheck(t)
+	const workers, ops = 8, 1000
+	r, err := ContentionCompare(workers, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ChanCount != workers*ops || r.MutexCount != workers*ops {
+		t.Errorf("final counts chan=%d mutex=%d, want %d", r.ChanCount, r.MutexCount, workers*ops)
+	}
+	if r.ChanNanos <= 0 || r.MutexNanos <= 0 {
+		t.Errorf("timings chan=%d mutex=%d, want > 0", r.ChanNanos, r.MutexNanos)
+	}
+	if r.ChanFair < 0 || r.MutexFair < 0 {
+		t.Errorf("coefficients of variation chan=%v mutex=%v, want >= 0", r.ChanFair, r.MutexFair)
+	}
+}
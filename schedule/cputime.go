@@ -0,0 +1,63 @@
+package schedule
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrUnsupported 表示当前平台不支持这个演示
+var ErrUnsupported = errors.New("schedule: not supported on this platform")
+
+// CPUAccounting 启动 n 个G, 每个G执行 workload(i), 返回每个G实际占用CPU的时间
+//
+// runtime 没有暴露每个G的CPU时间, 所以这里用 LockOSThread 把G绑定到一个线程上, 然后读线程的CPU时钟.
+// 绑定之后这个M只会运行这一个G, 线程的CPU时间就是G的CPU时间. 目前只支持 linux, 其他平台返回 ErrUnsupported.
+// n < 1 或者 workload 为 nil 返回错误
+func CPUAccounting(workload func(tid int), n int) ([]time.Duration, error) {
+	if !threadCPUTimeSupported {
+		return nil, ErrUnsupported
+	}
+	if err := validateMin("n", n, 1); err != nil {
+		return nil, err
+	}
+	if workload == nil {
+		return nil, errors.New("schedule: nil workload")
+	}
+
+	var (
+		times = make([]time.Duration, n)
+		errs  = make([]error, n)
+		wg    sync.WaitGroup
+	)
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+
+			start, err := threadCPUTime()
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			workload(i)
+			end, err := threadCPUTime()
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			times[i] = end - start
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return times, nil
+}
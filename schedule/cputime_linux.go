@@ -0,0 +1,22 @@
+package schedule
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const threadCPUTimeSupported = true
+
+// CLOCK_THREAD_CPUTIME_ID, 见 <linux/time.h>
+const clockThreadCPUTimeID = 3
+
+// threadCPUTime 返回当前线程的CPU时间, 调用方必须已经 LockOSThread
+func threadCPUTime() (time.Duration, error) {
+	var ts syscall.Timespec
+	_, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockThreadCPUTimeID, uintptr(unsafe.Pointer(&ts)), 0)
+	if errno != 0 {
+		return 0, errno
+	}
+	return time.Duration(ts.Nano()), nil
+}
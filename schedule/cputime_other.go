@@ -0,0 +1,11 @@
+//go:build !linux
+
+package schedule
+
+import "time"
+
+const threadCPUTimeSupported = false
+
+func threadCPUTime() (time.Duration, error) {
+	return 0, ErrUnsupported
+}
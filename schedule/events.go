@@ -0,0 +1,43 @@
+package schedule
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// 演示里记录的调度事件, 是 slog.Record 的 Message
+const (
+	eventSpawned  = "goroutine spawned"
+	eventYield    = "yield called"
+	eventDeadline = "deadline reached"
+	eventExited   = "goroutine exited"
+)
+
+// discardLogger 是 Options.Logger 为 nil 时用的 logger, Enabled 总是返回 false, 所以死循环里记日志也几乎没有开销
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// events 给一个演示的事件加上 demo, goroutine_index, elapsed_ns 三个属性
+type events struct {
+	logger *slog.Logger
+	demo   string
+	t0     time.Time
+}
+
+// start 记录 elapsed_ns 的起点并返回
+func (e *events) start() time.Time {
+	e.t0 = time.Now()
+	return e.t0
+}
+
+// log 记录 index 号G的一个事件, ctx 只是传给 Handler, 已经取消了也会记录
+func (e *events) log(ctx context.Context, msg string, index int) {
+	if !e.logger.Enabled(ctx, slog.LevelInfo) {
+		return
+	}
+	e.logger.LogAttrs(ctx, slog.LevelInfo, msg,
+		slog.String("demo", e.demo),
+		slog.Int("goroutine_index", index),
+		slog.Int64("elapsed_ns", time.Since(e.t0).Nanoseconds()),
+	)
+}
@@ -0,0 +1,100 @@
+package schedule
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type capturedEvent struct {
+	msg     string
+	demo    string
+	index   int64
+	elapsed int64
+}
+
+// captureHandler 把所有记录存下来, 演示里的G会并发地记日志, 所以要加锁
+type captureHandler struct {
+	mu     sync.Mutex
+	events []capturedEvent
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *captureHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	e := capturedEvent{msg: r.Message, index: -1, elapsed: -1}
+	r.Attrs(func(a slog.Attr) bool {
+		switch a.Key {
+		case "demo":
+			e.demo = a.Value.String()
+		case "goroutine_index":
+			e.index = a.Value.Int64()
+		case "elapsed_ns":
+			e.elapsed = a.Value.Int64()
+		}
+		return true
+	})
+	h.mu.Lock()
+	h.events = append(h.events, e)
+	h.mu.Unlock()
+	return nil
+}
+
+func TestStarvationDemoEvents(t *testing.T) {
+	LeakCheck(t)
+	for _, yield := range []bool{false, true} {
+		h := &captureHandler{}
+		opts := Options{Procs: 1, Duration: 20 * time.Millisecond, Yield: yield, Output: io.Discard, Logger: slog.New(h)}
+		if _, err := StarvationDemo(context.Background(), opts); err != nil {
+			t.Fatal(err)
+		}
+
+		// 按G分开, 每个G自己的事件是有序的
+		perG := map[int64][]string{}
+		yields := 0
+		last := map[int64]int64{}
+		for _, e := range h.events {
+			if e.demo != "starvation" || e.elapsed < 0 {
+				t.Fatalf("yield=%t: bad attributes %+v", yield, e)
+			}
+			if e.elapsed < last[e.index] {
+				t.Errorf("yield=%t: elapsed_ns went backwards for goroutine %d: %+v", yield, e.index, e)
+			}
+			last[e.index] = e.elapsed
+			if e.msg == eventYield {
+				if e.index != 0 {
+					t.Errorf("yield=%t: yield event from goroutine %d, only A yields", yield, e.index)
+				}
+				yields++
+				continue
+			}
+			perG[e.index] = append(perG[e.index], e.msg)
+		}
+
+		want := []string{eventSpawned, eventDeadline, eventExited}
+		for _, i := range []int64{0, 1} {
+			if !reflect.DeepEqual(perG[i], want) {
+				t.Errorf("yield=%t: goroutine %d events = %q, want %q", yield, i, perG[i], want)
+			}
+		}
+		if yield && yields == 0 {
+			t.Error("Yield=true but no yield events")
+		}
+		if !yield && yields != 0 {
+			t.Errorf("Yield=false but %d yield events", yields)
+		}
+	}
+}
+
+func TestDefaultLoggerDiscards(t *testing.T) {
+	var opts Options
+	if opts.logger().Enabled(context.Background(), slog.LevelError) {
+		t.Error("default logger is enabled, want it to discard everything")
+	}
+}
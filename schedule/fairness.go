@@ -0,0 +1,17 @@
+package schedule
+
+// JainFairness 计算 Jain 公平性指数 (Σx)² / (n·Σx²), 取值范围 (0, 1]
+//
+// 1 表示每个G分到的一样多, 只有一个G能跑的时候是 1/n. 空的或者全是0的时候返回0
+func JainFairness(counts []int64) float64 {
+	var sum, sq float64
+	for _, c := range counts {
+		x := float64(c)
+		sum += x
+		sq += x * x
+	}
+	if sq == 0 {
+		return 0
+	}
+	return sum * sum / (float64(len(counts)) * sq)
+}
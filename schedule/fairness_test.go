@@ -0,0 +1,35 @@
+package schedule
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestJainFairness(t *testing.T) {
+	tests := []struct {
+		counts []int64
+		want   float64
+	}{
+		{nil, 0},
+		{[]int64{}, 0},
+		{[]int64{0, 0, 0}, 0},
+		{[]int64{5}, 1},
+		{[]int64{1, 1, 1, 1}, 1},
+		// (1)² / (2·1) = 1/2, 只有一个能跑就是 1/n
+		{[]int64{1, 0}, 0.5},
+		{[]int64{7, 0, 0, 0}, 0.25},
+		// (3+1)² / (2·(9+1)) = 16/20
+		{[]int64{3, 1}, 0.8},
+		// (1+2+3)² / (3·(1+4+9)) = 36/42
+		{[]int64{1, 2, 3}, 6.0 / 7},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.counts), func(t *testing.T) {
+			got := JainFairness(tt.counts)
+			if math.IsNaN(got) || math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("JainFairness(%v) = %v, want %v", tt.counts, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package schedule
+
+import "sync"
+
+// StartGate 让一组G在同一时刻开始执行, 比 time.Sleep 等一会儿靠谱
+//
+// 编排者先 Add(n), 每个 worker 调用 Ready() 报到并阻塞, 编排者调用 Go() 等所有 worker 报到之后一起放行.
+// 放行是通过 close channel 广播的, 所以 Go() 多次调用是安全的, Go() 之后再调用 Ready() 会直接返回.
+// 零值可以直接使用
+type StartGate struct {
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	pending  int
+	released bool
+	initOnce sync.Once
+	goOnce   sync.Once
+	ch       chan struct{}
+}
+
+func (g *StartGate) init() {
+	g.initOnce.Do(func() { g.ch = make(chan struct{}) })
+}
+
+// Add 登记 n 个需要报到的 worker
+func (g *StartGate) Add(n int) {
+	g.init()
+	g.mu.Lock()
+	g.pending += n
+	g.mu.Unlock()
+	g.wg.Add(n)
+}
+
+// Ready 由 worker 调用, 阻塞到 Go() 放行为止
+func (g *StartGate) Ready() {
+	g.init()
+	g.mu.Lock()
+	if g.released {
+		g.mu.Unlock()
+		return
+	}
+	// 没有登记过的 Ready 不能 Done, 否则 WaitGroup 计数会变成负数
+	counted := g.pending > 0
+	if counted {
+		g.pending--
+	}
+	g.mu.Unlock()
+
+	if counted {
+		g.wg.Done()
+	}
+	<-g.ch
+}
+
+// Go 等所有登记的 worker 都调用了 Ready 之后, 一起放行
+func (g *StartGate) Go() {
+	g.init()
+	g.wg.Wait()
+	g.goOnce.Do(func() {
+		g.mu.Lock()
+		g.released = true
+		g.mu.Unlock()
+		close(g.ch)
+	})
+}
@@ -0,0 +1,70 @@
+package schedule
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// within 在 d 之内等 fn 返回, 超时就报错
+func within(t *testing.T, d time.Duration, what string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(d):
+		t.Fatalf("%s did not return within %v", what, d)
+	}
+}
+
+func TestStartGate(t *testing.T) {
+	LeakCheck(t)
+	const n = 10
+	var (
+		gate    StartGate
+		started int32
+		wg      sync.WaitGroup
+	)
+	gate.Add(n)
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			gate.Ready()
+			atomic.AddInt32(&started, 1)
+		}()
+	}
+	// Go 会等所有 worker 报到, 在这之前没有一个能越过 Ready
+	time.Sleep(10 * time.Millisecond)
+	if s := atomic.LoadInt32(&started); s != 0 {
+		t.Fatalf("%d workers started before Go", s)
+	}
+	within(t, 5*time.Second, "Go", gate.Go)
+	within(t, 5*time.Second, "workers", wg.Wait)
+	if s := atomic.LoadInt32(&started); s != n {
+		t.Errorf("%d workers started, want %d", s, n)
+	}
+}
+
+func TestStartGateGoTwice(t *testing.T) {
+	var gate StartGate
+	gate.Add(1)
+	go gate.Ready()
+	within(t, 5*time.Second, "first Go", gate.Go)
+	// 第二次 close 同一个 channel 会 panic, Go 必须是幂等的
+	within(t, 5*time.Second, "second Go", gate.Go)
+}
+
+func TestStartGateReadyAfterGo(t *testing.T) {
+	var gate StartGate
+	within(t, 5*time.Second, "Go with no workers", gate.Go)
+	within(t, time.Second, "Ready after Go", gate.Ready)
+	// Go 之后再 Add 的 worker 也不会被挡住
+	gate.Add(1)
+	within(t, time.Second, "Ready after Add after Go", gate.Ready)
+}
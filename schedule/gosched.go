@@ -0,0 +1,37 @@
+package schedule
+
+import (
+	"runtime"
+	"time"
+)
+
+// 每循环多少次看一次时间, 看时间本身也有开销, 不需要每次都看
+const clockCheckEvery = 256
+
+// GoschedCounter 用来测量一个CPU密集的循环主动让出P的频率, 多次 Spin 的结果会累加到 Loops, Yields 上
+type GoschedCounter struct {
+	Loops  int64
+	Yields int64
+}
+
+// Spin 执行死循环 d 这么长时间, 每 yieldEvery 次调用一次 runtime.Gosched(), yieldEvery <= 0 表示从不让出
+//
+// 在单P的情况下, yieldEvery 越小其他G得到调度的机会越多, 但是循环本身的吞吐量越低.
+// 看时间用的是 nanotime, 不会引入协作式抢占点, 所以 GODEBUG=asyncpreemptoff=1 的时候 yieldEvery <= 0 其他G完全拿不到P
+func (c *GoschedCounter) Spin(d time.Duration, yieldEvery int) (loops int64, yields int64) {
+	deadline := nanotime() + int64(d)
+	for {
+		loops++
+		if yieldEvery > 0 && loops%int64(yieldEvery) == 0 {
+			runtime.Gosched()
+			yields++
+		}
+		if loops%clockCheckEvery == 0 && nanotime() >= deadline {
+			break
+		}
+	}
+
+	c.Loops += loops
+	c.Yields += yields
+	return loops, yields
+}
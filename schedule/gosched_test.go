@@ -0,0 +1,74 @@
+package schedule
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// goschedTestChild 的参数是 yieldEvery, 在 GOMAXPROCS(1) 下 Spin 的同时让另一个G累加, 输出 Spin 结束时它累加的次数
+func goschedTestChild(arg string) {
+	yieldEvery, _ := strconv.Atoi(arg)
+	runtime.GOMAXPROCS(1)
+
+	var progress int64
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			atomic.AddInt64(&progress, 1)
+			runtime.Gosched()
+		}
+	}()
+
+	var c GoschedCounter
+	c.Spin(50*time.Millisecond, yieldEvery)
+	fmt.Println(atomic.LoadInt64(&progress))
+	close(stop)
+}
+
+// 在 GODEBUG=asyncpreemptoff=1 的子进程里跑, 否则 sysmon 会异步抢占 Spin, 后台的G不管怎样都能运行
+func TestGoschedCounterBackgroundProgress(t *testing.T) {
+	LeakCheck(t)
+	for _, yieldEvery := range []int{0, 1, 1000} {
+		t.Run(fmt.Sprintf("yieldEvery=%d", yieldEvery), func(t *testing.T) {
+			out := runChild(t, "test-gosched", strconv.Itoa(yieldEvery), "asyncpreemptoff=1")
+			progress, err := strconv.ParseInt(out, 10, 64)
+			if err != nil {
+				t.Fatalf("child output %q: %v", out, err)
+			}
+			if yieldEvery == 0 && progress != 0 {
+				t.Errorf("background progress = %d without yielding, want 0", progress)
+			}
+			if yieldEvery > 0 && progress == 0 {
+				t.Errorf("background made no progress with yieldEvery=%d", yieldEvery)
+			}
+		})
+	}
+}
+
+func TestGoschedCounterSpin(t *testing.T) {
+	LeakCheck(t)
+	prev := runtime.GOMAXPROCS(1)
+	defer runtime.GOMAXPROCS(prev)
+
+	var c GoschedCounter
+	loops, yields := c.Spin(10*time.Millisecond, 0)
+	if loops == 0 || yields != 0 {
+		t.Errorf("yieldEvery=0: loops=%d yields=%d, want loops>0 yields=0", loops, yields)
+	}
+	loops2, yields2 := c.Spin(10*time.Millisecond, 10)
+	if yields2 != loops2/10 {
+		t.Errorf("yieldEvery=10: yields=%d, want loops/10=%d", yields2, loops2/10)
+	}
+	if c.Loops != loops+loops2 || c.Yields != yields+yields2 {
+		t.Errorf("counter = %+v, want accumulated %d/%d", c, loops+loops2, yields+yields2)
+	}
+}
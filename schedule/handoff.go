@@ -0,0 +1,56 @@
+package schedule
+
+import (
+	"runtime"
+	"time"
+)
+
+// HandoffReport 是 DirectHandoff 的结果
+type HandoffReport struct {
+	UnbufferedNanos int64 `json:"unbuffered_nanos"`
+	BufferedNanos   int64 `json:"buffered_nanos"`
+}
+
+// DirectHandoff 在 GOMAXPROCS(1) 下让两个G通过 channel 来回传递 iterations 次, 分别用无缓冲和容量为1的 channel
+//
+// 往无缓冲 channel 发送的时候如果已经有G在等着接收, chansend 会把数据直接拷贝到接收者的栈上, 然后 goready 把它放到 runnext,
+// 发送者紧接着阻塞在下一次接收上, 让出P之后接收者马上就能运行, 中间不经过队列. 有缓冲的 channel 数据要先进 buf 再被读出来,
+// 比较两者的耗时可以大概看出直接交接省掉了多少. iterations < 1 返回错误
+func DirectHandoff(iterations int) (HandoffReport, error) {
+	if err := validateMin("iterations", iterations, 1); err != nil {
+		return HandoffReport{}, err
+	}
+
+	prev := runtime.GOMAXPROCS(1)
+	defer runtime.GOMAXPROCS(prev)
+
+	return HandoffReport{
+		UnbufferedNanos: pingPong(iterations, 0),
+		BufferedNanos:   pingPong(iterations, 1),
+	}, nil
+}
+
+func pingPong(iterations, size int) int64 {
+	ping := make(chan int, size)
+	pong := make(chan int, size)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for v := range ping {
+			pong <- v + 1
+		}
+	}()
+
+	start := time.Now()
+	v := 0
+	for i := 0; i < iterations; i++ {
+		ping <- v
+		v = <-pong
+	}
+	nanos := time.Since(start).Nanoseconds()
+
+	close(ping)
+	<-done
+	return nanos
+}
@@ -0,0 +1,14 @@
+package schedule
+
+import "testing"
+
+func TestDirectHandoff(t *testing.T) {
+	LeakCheck(t)
+	r, err := DirectHandoff(10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.UnbufferedNanos <= 0 || r.BufferedNanos <= 0 {
+		t.Errorf("%+v, want both timings > 0", r)
+	}
+}
@@ -0,0 +1,166 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// 默认的桶上界, 单位微秒
+var defaultLatencyBounds = []int64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000, 20000, 50000, 100000}
+
+// Histogram 是调度延迟的直方图
+type Histogram struct {
+	// 每个桶的上界(包含), 单位微秒, 从小到大
+	BoundsMicros []int64 `json:"bounds_micros"`
+	// len(Counts) == len(BoundsMicros)+1, 最后一个桶是超过最大上界的
+	Counts []int64 `json:"counts"`
+	// 样本总数
+	Total int64 `json:"total"`
+	// 最大延迟
+	Max time.Duration `json:"max"`
+}
+
+// Quantile 返回第 q(0~1) 分位所在的桶的上界, 落在最后一个桶的时候返回 Max
+func (h Histogram) Quantile(q float64) time.Duration {
+	if h.Total == 0 {
+		return 0
+	}
+	target := int64(q * float64(h.Total))
+	if target < 1 {
+		target = 1
+	}
+	var seen int64
+	for i, c := range h.Counts {
+		seen += c
+		if seen >= target {
+			if i < len(h.BoundsMicros) {
+				return time.Duration(h.BoundsMicros[i]) * time.Microsecond
+			}
+			break
+		}
+	}
+	return h.Max
+}
+
+// P50 中位数
+func (h Histogram) P50() time.Duration { return h.Quantile(0.5) }
+
+// P99 99分位
+func (h Histogram) P99() time.Duration { return h.Quantile(0.99) }
+
+// newHistogram 用 bounds 做桶的上界, 空的时候用 defaultLatencyBounds.
+// 桶是按微秒算的, 所以每个上界都至少要1us, 换算成微秒之后还要严格递增, 否则 observe 和 Quantile 找桶的时候会出错
+func newHistogram(bounds []time.Duration) (Histogram, error) {
+	h := Histogram{BoundsMicros: defaultLatencyBounds}
+	if len(bounds) > 0 {
+		h.BoundsMicros = make([]int64, len(bounds))
+		for i, b := range bounds {
+			us := b.Microseconds()
+			if us < 1 {
+				return Histogram{}, fmt.Errorf("schedule: latency bound %v is below 1us", b)
+			}
+			if i > 0 && us <= h.BoundsMicros[i-1] {
+				return Histogram{}, fmt.Errorf("schedule: latency bounds must be ascending in whole microseconds, %v follows %v", b, bounds[i-1])
+			}
+			h.BoundsMicros[i] = us
+		}
+	}
+	h.Counts = make([]int64, len(h.BoundsMicros)+1)
+	return h, nil
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	us := d.Microseconds()
+	i := 0
+	for i < len(h.BoundsMicros) && us > h.BoundsMicros[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Total++
+	if d > h.Max {
+		h.Max = d
+	}
+}
+
+// LatencyHistogram 测量从G变成 runnable 到真正开始运行的延迟
+//
+// 每个 producer 对应一个 consumer, producer 每毫秒把 ticker 触发的时间通过无缓冲 channel 发给 consumer,
+// 发送会把阻塞在接收上的 consumer 唤醒(goready 放到 runnext), consumer 拿到之后和当前时间比较,
+// 所以测到的延迟包括 producer 被 timer 唤醒和 consumer 被 producer 唤醒两次调度.
+// 如果同时有死循环的G占着P(比如 GOMAXPROCS(1)), P99 会明显变大.
+// 持续 d 或者直到 ctx 被取消, 每个 consumer 直接记到自己的直方图里, 不保留样本, d 很长也不会占太多内存.
+// bounds 可以指定桶的上界, 不传就用默认的 1us~100ms. procs, d 不合法, producers < 1, 或者 bounds 不是从1us起严格递增的都返回错误
+func LatencyHistogram(ctx context.Context, procs, producers int, d time.Duration, bounds ...time.Duration) (Histogram, error) {
+	opts := Options{Procs: procs, Duration: d}
+	if err := opts.Validate(); err != nil && !IsWarning(err) {
+		return Histogram{}, err
+	}
+	if err := validateMin("producers", producers, 1); err != nil {
+		return Histogram{}, err
+	}
+	h, err := newHistogram(bounds)
+	if err != nil {
+		return Histogram{}, err
+	}
+
+	prev := runtime.GOMAXPROCS(opts.Procs)
+	defer runtime.GOMAXPROCS(prev)
+
+	var (
+		hs   = make([]Histogram, producers)
+		wg   sync.WaitGroup
+		gate StartGate
+	)
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	wg.Add(2 * producers)
+	gate.Add(producers)
+	for i := 0; i < producers; i++ {
+		ch := make(chan time.Time)
+		hs[i] = h
+		hs[i].Counts = make([]int64, len(h.Counts))
+
+		// producer
+		go func() {
+			defer wg.Done()
+			defer close(ch)
+			gate.Ready()
+			tick := time.NewTicker(time.Millisecond)
+			defer tick.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case t := <-tick.C:
+					ch <- t
+				}
+			}
+		}()
+
+		// consumer
+		go func(c *Histogram) {
+			defer wg.Done()
+			for sent := range ch {
+				c.observe(time.Since(sent))
+			}
+		}(&hs[i])
+	}
+	gate.Go()
+	wg.Wait()
+
+	for _, c := range hs {
+		for j, n := range c.Counts {
+			h.Counts[j] += n
+		}
+		h.Total += c.Total
+		if c.Max > h.Max {
+			h.Max = c.Max
+		}
+	}
+	return h, nil
+}
@@ -0,0 +1,85 @@
+package schedule
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	LeakCheck(t)
+	h, err := LatencyHistogram(context.Background(), 1, 2, 50*time.Millisecond, 100*time.Microsecond, time.Millisecond, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{100, 1000, 10000}; !reflect.DeepEqual(h.BoundsMicros, want) {
+		t.Errorf("BoundsMicros = %v, want %v", h.BoundsMicros, want)
+	}
+	var sum int64
+	for _, c := range h.Counts {
+		sum += c
+	}
+	if h.Total == 0 || sum != h.Total {
+		t.Errorf("Total = %d, sum(Counts) = %d, want equal and > 0", h.Total, sum)
+	}
+	if h.P50() > h.P99() {
+		t.Errorf("P50 %v, P99 %v, Max %v out of order", h.P50(), h.P99(), h.Max)
+	}
+}
+
+func TestLatencyHistogramStopsOnCancel(t *testing.T) {
+	LeakCheck(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	h, err := LatencyHistogram(ctx, 1, 2, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("returned %v after cancel, want promptly", elapsed)
+	}
+	if h.Total == 0 {
+		t.Error("no samples before cancel")
+	}
+}
+
+func TestLatencyHistogramBadBounds(t *testing.T) {
+	for _, bounds := range [][]time.Duration{
+		{0},
+		{-time.Millisecond},
+		{500 * time.Nanosecond, time.Millisecond},
+		{time.Millisecond, 100 * time.Microsecond},
+		{time.Millisecond, time.Millisecond},
+		// 换算成微秒之后都是1
+		{1500 * time.Nanosecond, 1700 * time.Nanosecond},
+	} {
+		if _, err := LatencyHistogram(context.Background(), 1, 1, time.Millisecond, bounds...); err == nil {
+			t.Errorf("bounds %v accepted", bounds)
+		}
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	h, err := newHistogram([]time.Duration{time.Microsecond, 10 * time.Microsecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.P50() != 0 {
+		t.Errorf("empty P50 = %v, want 0", h.P50())
+	}
+	for _, d := range []time.Duration{500 * time.Nanosecond, 5 * time.Microsecond, 7 * time.Microsecond, time.Millisecond} {
+		h.observe(d)
+	}
+	if want := []int64{1, 2, 1}; !reflect.DeepEqual(h.Counts, want) {
+		t.Errorf("Counts = %v, want %v", h.Counts, want)
+	}
+	if got := h.P50(); got != 10*time.Microsecond {
+		t.Errorf("P50 = %v, want 10us", got)
+	}
+	// 落在最后一个桶, 返回 Max
+	if got := h.Quantile(1); got != time.Millisecond {
+		t.Errorf("Quantile(1) = %v, want Max 1ms", got)
+	}
+}
@@ -0,0 +1,133 @@
+package schedule
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leakGrace 是判定泄漏之前等待的时间, 刚被取消的G可能还在 time.Sleep 里, 需要给它一点时间退出
+const leakGrace = time.Second
+
+// 这些函数出现在栈里说明是 runtime 或者 testing 自己的G, 不算泄漏
+var ignoredStacks = []string{
+	"testing.RunTests(",
+	"testing.(*T).Run(",
+	"testing.(*T).Parallel(",
+	"testing.(*M).",
+	"testing.runFuzzing(",
+	"os/signal.signal_recv(",
+	"os/signal.loop(",
+	"runtime.ensureSigM(",
+	"runtime/trace.Start.",
+	"runtime.goexit0(",
+}
+
+// LeakCheck 记录当前所有的G, 在 t 结束的时候检查有没有新增的G没有退出, 有的话把它们的栈报告为错误
+//
+//	func TestDemo(t *testing.T) {
+//		schedule.LeakCheck(t)
+//		...
+//	}
+func LeakCheck(t testing.TB) {
+	t.Helper()
+	before := make(map[uint64]bool)
+	for _, g := range goroutineStacks() {
+		before[g.id] = true
+	}
+
+	t.Cleanup(func() {
+		leaked := waitLeaked(func(gs []goroutineStack) []string {
+			var out []string
+			for _, g := range gs {
+				if !before[g.id] {
+					out = append(out, g.stack)
+				}
+			}
+			return out
+		})
+		for _, s := range leaked {
+			t.Errorf("leaked goroutine:\n%s", s)
+		}
+	})
+}
+
+// CountLeaked 在宽限期内等待 runtime.NumGoroutine() 回到 baseline, 超时后返回除了当前G以外其他G的栈
+//
+// 只比较数量, 没有 LeakCheck 精确, 适合在没有 testing.TB 的地方用
+func CountLeaked(baseline int) []string {
+	return waitLeaked(func(gs []goroutineStack) []string {
+		if runtime.NumGoroutine() <= baseline {
+			return nil
+		}
+		out := make([]string, 0, len(gs))
+		for _, g := range gs {
+			out = append(out, g.stack)
+		}
+		return out
+	})
+}
+
+// waitLeaked 反复调用 find, 直到它返回空或者超过 leakGrace
+func waitLeaked(find func([]goroutineStack) []string) []string {
+	deadline := time.Now().Add(leakGrace)
+	for {
+		leaked := find(goroutineStacks())
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type goroutineStack struct {
+	id    uint64
+	stack string
+}
+
+// goroutineStacks 返回除了当前G以及 runtime/testing 自己的G以外所有G的栈
+func goroutineStacks() []goroutineStack {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	// runtime.Stack 输出的第一个总是当前G
+	stanzas := strings.Split(string(buf), "\n\n")[1:]
+	out := make([]goroutineStack, 0, len(stanzas))
+	for _, s := range stanzas {
+		id, ok := parseGoroutineHeader(s)
+		if !ok || ignoredStack(s) {
+			continue
+		}
+		out = append(out, goroutineStack{id: id, stack: s})
+	}
+	return out
+}
+
+// parseGoroutineHeader 解析 "goroutine 42 [running]:" 里的 42
+func parseGoroutineHeader(s string) (uint64, bool) {
+	s = strings.TrimPrefix(s, "goroutine ")
+	i := strings.IndexByte(s, ' ')
+	if i < 0 {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(s[:i], 10, 64)
+	return id, err == nil
+}
+
+func ignoredStack(s string) bool {
+	for _, ig := range ignoredStacks {
+		if strings.Contains(s, ig) {
+			return true
+		}
+	}
+	return false
+}
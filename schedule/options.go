@@ -0,0 +1,83 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+)
+
+// Options 是各个演示共用的参数
+type Options struct {
+	// GOMAXPROCS
+	Procs int
+	// 演示持续的时间
+	Duration time.Duration
+	// 死循环里是否调用 runtime.Gosched()
+	Yield bool
+	// 演示过程中的输出, nil 表示 os.Stdout
+	Output io.Writer
+	// 调度相关事件的结构化日志, nil 表示丢弃, 见 events.go
+	Logger *slog.Logger
+}
+
+func (o *Options) output() io.Writer {
+	if o.Output == nil {
+		return os.Stdout
+	}
+	return o.Output
+}
+
+func (o *Options) logger() *slog.Logger {
+	if o.Logger == nil {
+		return discardLogger
+	}
+	return o.Logger
+}
+
+var (
+	errProcs    = errors.New("schedule: Procs must be >= 1")
+	errDuration = errors.New("schedule: Duration must be > 0")
+)
+
+// validateMin 检查演示里 Options 以外的数量参数(G的个数, 循环次数等), 小于 min 返回错误
+func validateMin(name string, n, min int) error {
+	if n < min {
+		return fmt.Errorf("schedule: %s must be >= %d, got %d", name, min, n)
+	}
+	return nil
+}
+
+// Warning 是 Validate 返回的不影响运行的问题, 返回 Warning 的时候 Options 已经被修正过了, 可以继续使用
+type Warning struct {
+	Msg string
+}
+
+func (w *Warning) Error() string { return "schedule: " + w.Msg }
+
+// IsWarning 判断 Validate 返回的 err 是不是只是一个 Warning
+func IsWarning(err error) bool {
+	var w *Warning
+	return errors.As(err, &w)
+}
+
+// Validate 检查参数, Procs < 1 或者 Duration <= 0 返回错误.
+// Procs 比 CPU 核数还多的时候改成 runtime.NumCPU() 并返回一个 *Warning.
+// Validate 不会修改 GOMAXPROCS, 演示自己负责设置和恢复
+func (o *Options) Validate() error {
+	if o.Procs < 1 {
+		return errProcs
+	}
+	if o.Duration <= 0 {
+		return errDuration
+	}
+	if n := runtime.NumCPU(); o.Procs > n {
+		w := &Warning{Msg: fmt.Sprintf("Procs %d exceeds NumCPU %d, using %d", o.Procs, n, n)}
+		o.Procs = n
+		return w
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	n := runtime.NumCPU()
+	tests := []struct {
+		name      string
+		opts      Options
+		wantErr   error
+		warning   bool
+		wantProcs int
+	}{
+		{name: "ok", opts: Options{Procs: 1, Duration: time.Second}, wantProcs: 1},
+		{name: "procs=0", opts: Options{Procs: 0, Duration: time.Second}, wantErr: errProcs, wantProcs: 0},
+		{name: "procs<0", opts: Options{Procs: -3, Duration: time.Second}, wantErr: errProcs, wantProcs: -3},
+		{name: "duration=0", opts: Options{Procs: 1}, wantErr: errDuration, wantProcs: 1},
+		{name: "duration<0", opts: Options{Procs: 1, Duration: -time.Second}, wantErr: errDuration, wantProcs: 1},
+		{name: "procs>NumCPU", opts: Options{Procs: n + 1, Duration: time.Second}, warning: true, wantProcs: n},
+	}
+
+	prev := runtime.GOMAXPROCS(0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			err := opts.Validate()
+			switch {
+			case tt.warning:
+				var w *Warning
+				if !errors.As(err, &w) || !IsWarning(err) {
+					t.Errorf("Validate() = %v, want *Warning", err)
+				}
+			case err != tt.wantErr:
+				t.Errorf("Validate() = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && IsWarning(err) {
+				t.Errorf("IsWarning(%v) = true for a real error", err)
+			}
+			if opts.Procs != tt.wantProcs {
+				t.Errorf("Procs = %d after Validate, want %d", opts.Procs, tt.wantProcs)
+			}
+		})
+	}
+	if got := runtime.GOMAXPROCS(0); got != prev {
+		t.Errorf("Validate changed GOMAXPROCS from %d to %d", prev, got)
+	}
+}
+
+// 每个演示的入口在参数不合法的时候都应该直接返回错误, 不应该 panic 或者开始跑
+func TestEntryPointsRejectBadInput(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"StarvationDemo procs", func() error {
+			_, err := StarvationDemo(context.Background(), Options{Procs: 0, Duration: time.Second})
+			return err
+		}},
+		{"StarvationDemo duration", func() error {
+			_, err := StarvationDemo(context.Background(), Options{Procs: 1})
+			return err
+		}},
+		{"AsyncPreempt procs", func() error { _, err := AsyncPreempt(ctx, 0, time.Second); return err }},
+		{"AsyncPreempt duration", func() error { _, err := AsyncPreempt(ctx, 1, 0); return err }},
+		{"SysmonPreempt duration", func() error { _, err := SysmonPreempt(ctx, -time.Second); return err }},
+		{"SysmonPreemptThreshold threshold", func() error { _, err := SysmonPreemptThreshold(ctx, time.Second, 0); return err }},
+		{"WorkSteal goroutines", func() error { _, err := WorkSteal(0, 1); return err }},
+		{"WorkSteal procs", func() error { _, err := WorkSteal(10, -1); return err }},
+		{"LatencyHistogram procs", func() error { _, err := LatencyHistogram(ctx, 0, 1, time.Second); return err }},
+		{"LatencyHistogram producers", func() error { _, err := LatencyHistogram(ctx, 1, -1, time.Second); return err }},
+		{"LatencyHistogram duration", func() error { _, err := LatencyHistogram(ctx, 1, 1, 0); return err }},
+		{"PinnedThroughput workers", func() error { _, err := PinnedThroughput(true, -1, time.Second); return err }},
+		{"PinnedThroughput duration", func() error { _, err := PinnedThroughput(false, 1, 0); return err }},
+		{"CPUAccounting n", func() error { _, err := CPUAccounting(func(int) {}, -1); return err }},
+		{"CPUAccounting workload", func() error { _, err := CPUAccounting(nil, 1); return err }},
+		{"DirectHandoff iterations", func() error { _, err := DirectHandoff(0); return err }},
+		{"ContentionCompare workers", func() error { _, err := ContentionCompare(0, 10); return err }},
+		{"ContentionCompare ops", func() error { _, err := ContentionCompare(2, 0); return err }},
+		{"CancelPropagation depth", func() error { _, err := CancelPropagation(-1); return err }},
+		{"BlockingSyscallThreads procs", func() error { _, err := BlockingSyscallThreads(0, 1); return err }},
+		{"BlockingSyscallThreads blockers", func() error { _, err := BlockingSyscallThreads(1, -1); return err }},
+		{"QueuePlacement spawnBatch", func() error { _, err := QueuePlacement(0); return err }},
+		{"SpinningThreads procs", func() error { _, err := SpinningThreads(0, 1); return err }},
+		{"SpinningThreads burst", func() error { _, err := SpinningThreads(1, -1); return err }},
+		{"AffinityThroughput workers", func() error { _, err := AffinityThroughput([]int{0}, 0, time.Second); return err }},
+		{"AffinityThroughput duration", func() error { _, err := AffinityThroughput([]int{0}, 1, 0); return err }},
+		{"AffinityThroughput cpuSet", func() error { _, err := AffinityThroughput(nil, 1, time.Second); return err }},
+		{"BackpressureFix procs", func() error { _, err := BackpressureFix(0, time.Second); return err }},
+		{"BackpressureFix duration", func() error { _, err := BackpressureFix(1, 0); return err }},
+		{"UnstealableLoad procs", func() error { _, err := UnstealableLoad(0, time.Second); return err }},
+		{"UnstealableLoad duration", func() error { _, err := UnstealableLoad(1, 0); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if err == nil || IsWarning(err) {
+				t.Errorf("err = %v, want a rejection", err)
+			}
+		})
+	}
+}
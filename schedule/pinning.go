@@ -0,0 +1,58 @@
+package schedule
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// PinnedThroughput 启动 workers 个CPU密集的G跑 d 这么长时间, 返回每个G的循环次数
+//
+// pin 为 true 的时候每个G会 LockOSThread, 这个G只会在自己的M上运行, 这个M也不会再运行其他G.
+// 注意绑定之后的G阻塞的时候它的M不能去运行别的G, runtime 需要另外创建M来运行其他G,
+// 所以实际的线程数可能超过 GOMAXPROCS(GOMAXPROCS 限制的是P, 也就是同时运行Go代码的M的数量).
+// workers < 1 或者 d <= 0 返回错误
+func PinnedThroughput(pin bool, workers int, d time.Duration) ([]int64, error) {
+	if err := validateMin("workers", workers, 1); err != nil {
+		return nil, err
+	}
+	if d <= 0 {
+		return nil, errDuration
+	}
+
+	var (
+		loops = make([]int64, workers)
+		wg    sync.WaitGroup
+		gate  StartGate
+	)
+
+	wg.Add(workers)
+	gate.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func(i int) {
+			defer wg.Done()
+			if pin {
+				runtime.LockOSThread()
+				// 循环因为超时退出也要解绑
+				defer runtime.UnlockOSThread()
+			}
+			gate.Ready()
+			loops[i] = spinFor(d)
+		}(i)
+	}
+	gate.Go()
+	wg.Wait()
+	return loops, nil
+}
+
+// spinFor 空转 d 这么长时间, 返回循环次数
+func spinFor(d time.Duration) int64 {
+	deadline := time.Now().Add(d)
+	var n int64
+	for {
+		n++
+		if n%clockCheckEvery == 0 && !time.Now().Before(deadline) {
+			return n
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package schedule
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chidaren/read-go-source-code/schedule/schedtrace"
+)
+
+// PlacementReport 是 QueuePlacement 的结果
+type PlacementReport struct {
+	// schedtrace 里看到的最长的P本地队列
+	LocalQueueMax int `json:"local_queue_max"`
+	// schedtrace 里是否看到过全局队列不为空
+	GlobalQueueSeen bool `json:"global_queue_seen"`
+}
+
+// QueuePlacement 在子进程里由一个G连续创建 spawnBatch 个G, 通过 GODEBUG=schedtrace=1 的输出观察G被放到了哪个队列
+//
+// P的本地队列最多256个, 满了之后 runqputslow 会把一半挪到全局队列, 所以 spawnBatch 超过256就应该能看到全局队列. spawnBatch < 1 返回错误
+func QueuePlacement(spawnBatch int) (PlacementReport, error) {
+	var r PlacementReport
+	if err := validateMin("spawnBatch", spawnBatch, 1); err != nil {
+		return r, err
+	}
+
+	cmd, err := childCommand("placement", strconv.Itoa(spawnBatch), "schedtrace=1")
+	if err != nil {
+		return r, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return r, fmt.Errorf("schedule: placement child: %v: %s", err, stderr.Bytes())
+	}
+
+	lines, err := schedtrace.Parse(&stderr)
+	if err != nil {
+		return r, err
+	}
+	for _, l := range lines {
+		if l.RunQueue > 0 {
+			r.GlobalQueueSeen = true
+		}
+		for _, n := range l.PerPRunQueues {
+			if n > r.LocalQueueMax {
+				r.LocalQueueMax = n
+			}
+		}
+	}
+	return r, nil
+}
+
+// placementChild 是子进程里跑的 workload, 每个G空转一小会儿, 保证队列来不及被消化
+func placementChild(arg string) {
+	n, _ := strconv.Atoi(arg)
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			deadline := time.Now().Add(100 * time.Microsecond)
+			for time.Now().Before(deadline) {
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 多等几个 schedtrace 周期再退出
+	time.Sleep(5 * time.Millisecond)
+}
@@ -0,0 +1,18 @@
+package schedule
+
+import "testing"
+
+// 一次创建的G超过本地队列的256个, runqputslow 会把一半挪到全局队列
+func TestQueuePlacement(t *testing.T) {
+	LeakCheck(t)
+	r, err := QueuePlacement(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.GlobalQueueSeen {
+		t.Errorf("%+v, want the global queue to be used", r)
+	}
+	if r.LocalQueueMax > 256 {
+		t.Errorf("LocalQueueMax = %d, local runq holds at most 256", r.LocalQueueMax)
+	}
+}
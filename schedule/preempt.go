@@ -0,0 +1,202 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+/*
+
+go1.14 之前, G 只会在函数调用的时候(函数头部检查 stackguard)被协作式抢占, 所以 gmp.go 里那种没有函数调用的死循环永远不会让出P.
+go1.14 之后, sysmon 发现一个G运行超过10ms, 会给它所在的M发送 SIGURG 信号, 信号处理函数把G的上下文改成调用 asyncPreempt,
+这样即使是没有函数调用的死循环也会被抢占, 单P的情况下其他G也能得到调度
+
+*/
+
+// PreemptReport 是 AsyncPreempt 的结果
+type PreemptReport struct {
+	// 观察者G在死循环期间被调度的次数
+	ObserverRuns int `json:"observer_runs"`
+	// runtime.Version()
+	GoVersion string `json:"go_version"`
+	// 按版本号判断是否支持异步抢占(>= go1.14)
+	AsyncExpected bool `json:"async_expected"`
+}
+
+// AsyncPreempt 在 GOMAXPROCS(procs) 下启动一个不让出的死循环G和一个观察者G, loopDuration 之后或者 ctx 被取消时返回观察者被调度的次数
+//
+// 如果支持异步抢占, 即使 procs=1 ObserverRuns 也会大于0. procs < 1 或者 loopDuration <= 0 返回 Validate 的错误
+func AsyncPreempt(ctx context.Context, procs int, loopDuration time.Duration) (PreemptReport, error) {
+	opts := Options{Procs: procs, Duration: loopDuration}
+	if err := opts.Validate(); err != nil && !IsWarning(err) {
+		return PreemptReport{}, err
+	}
+
+	prev := runtime.GOMAXPROCS(opts.Procs)
+	defer runtime.GOMAXPROCS(prev)
+
+	var (
+		runs int64
+		stop int32
+		done = make(chan struct{}, 2)
+	)
+
+	// 死循环, 没有任何函数调用
+	go func() {
+		defer func() { done <- struct{}{} }()
+		var n int64
+		for atomic.LoadInt32(&stop) == 0 {
+			n++
+		}
+	}()
+
+	// 观察者
+	go func() {
+		defer func() { done <- struct{}{} }()
+		for atomic.LoadInt32(&stop) == 0 {
+			atomic.AddInt64(&runs, 1)
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+	<-ctx.Done()
+	atomic.StoreInt32(&stop, 1)
+	<-done
+	<-done
+
+	v := runtime.Version()
+	return PreemptReport{
+		ObserverRuns:  int(atomic.LoadInt64(&runs)),
+		GoVersion:     v,
+		AsyncExpected: asyncPreemptSupported(v),
+	}, nil
+}
+
+// asyncPreemptSupported 判断版本号是否 >= go1.14
+func asyncPreemptSupported(version string) bool {
+	major, minor, ok := parseGoVersion(version)
+	if !ok {
+		// 解析不出来的基本都是 devel 版本, devel 比所有正式版本都新
+		return true
+	}
+	return major > 1 || (major == 1 && minor >= 14)
+}
+
+// parseGoVersion 解析 runtime.Version() 的主次版本号, 支持以下格式:
+//
+//	go1.22.3
+//	go1.21rc2
+//	go1.22-abcdef
+//	devel go1.23-abcdef Tue Jan 2 15:04:05 2024 +0000
+func parseGoVersion(version string) (major, minor int, ok bool) {
+	i := strings.Index(version, "go")
+	if i < 0 {
+		return 0, 0, false
+	}
+	v := version[i+2:]
+
+	// 只要 major.minor, 后面的 patch, rc, beta, -hash 都不关心
+	dot := strings.IndexByte(v, '.')
+	if dot <= 0 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(v[:dot])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	v = v[dot+1:]
+	end := 0
+	for end < len(v) && v[end] >= '0' && v[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return 0, 0, false
+	}
+	minor, err = strconv.Atoi(v[:end])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// DefaultSysmonThreshold 是 SysmonPreempt 判断抢占是否生效的默认阈值.
+// sysmon 的 forcePreemptNS 是10ms, 但是 sysmon 自己空闲的时候每次也要睡10ms才检查一次, 所以最长的延迟在20ms左右.
+// 阈值定得太宽会掩盖抢占失效的问题, 在负载高的机器上可以用 SysmonPreemptThreshold 放宽
+const DefaultSysmonThreshold = 25 * time.Millisecond
+
+// SysmonReport 是 SysmonPreempt 的结果
+type SysmonReport struct {
+	// 计时G在 sleep 1ms 之后实际多等的最长时间
+	MaxStall time.Duration `json:"max_stall"`
+	// MaxStall 小于阈值, 说明死循环的G被 sysmon 抢占了
+	PreemptionLikely bool `json:"preemption_likely"`
+}
+
+// SysmonPreempt 使用 DefaultSysmonThreshold 调用 SysmonPreemptThreshold
+func SysmonPreempt(ctx context.Context, loopDuration time.Duration) (SysmonReport, error) {
+	return SysmonPreemptThreshold(ctx, loopDuration, DefaultSysmonThreshold)
+}
+
+// SysmonPreemptThreshold 在 GOMAXPROCS(1) 下运行一个不让出的死循环G和一个每次 sleep 1ms 的计时G, 持续 loopDuration
+// 或者直到 ctx 被取消, 返回计时G最长被耽误了多久
+//
+// sysmon 发现一个G连续运行超过10ms会抢占它, 所以计时G的延迟应该在几十毫秒以内; 如果抢占没有生效, 延迟会接近 loopDuration.
+// loopDuration 和 threshold 都必须大于0
+func SysmonPreemptThreshold(ctx context.Context, loopDuration, threshold time.Duration) (SysmonReport, error) {
+	opts := Options{Procs: 1, Duration: loopDuration}
+	if err := opts.Validate(); err != nil {
+		return SysmonReport{}, err
+	}
+	if threshold <= 0 {
+		return SysmonReport{}, fmt.Errorf("schedule: threshold must be > 0, got %v", threshold)
+	}
+
+	prev := runtime.GOMAXPROCS(1)
+	defer runtime.GOMAXPROCS(prev)
+
+	var (
+		stop     int32
+		maxStall int64
+		done     = make(chan struct{}, 2)
+	)
+
+	go func() {
+		defer func() { done <- struct{}{} }()
+		var n int64
+		for atomic.LoadInt32(&stop) == 0 {
+			n++
+		}
+	}()
+
+	go func() {
+		defer func() { done <- struct{}{} }()
+		for atomic.LoadInt32(&stop) == 0 {
+			start := time.Now()
+			time.Sleep(time.Millisecond)
+			if stall := int64(time.Since(start) - time.Millisecond); stall > atomic.LoadInt64(&maxStall) {
+				atomic.StoreInt64(&maxStall, stall)
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, loopDuration)
+	defer cancel()
+	<-ctx.Done()
+	atomic.StoreInt32(&stop, 1)
+	<-done
+	<-done
+
+	stall := time.Duration(atomic.LoadInt64(&maxStall))
+	return SysmonReport{
+		MaxStall:         stall,
+		PreemptionLikely: stall < threshold,
+	}, nil
+}
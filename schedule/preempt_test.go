@@ -0,0 +1,66 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// 默认的 -d 1h 下 Ctrl-C 要能让 preempt 和 sysmon 马上返回
+func TestPreemptDemosStopOnCancel(t *testing.T) {
+	LeakCheck(t)
+	demos := map[string]func(ctx context.Context) error{
+		"AsyncPreempt": func(ctx context.Context) error {
+			_, err := AsyncPreempt(ctx, 1, time.Hour)
+			return err
+		},
+		"SysmonPreempt": func(ctx context.Context) error {
+			_, err := SysmonPreempt(ctx, time.Hour)
+			return err
+		},
+	}
+	for name, run := range demos {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			if err := run(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("returned %v after cancel, want promptly", elapsed)
+			}
+		})
+	}
+}
+
+func TestParseGoVersion(t *testing.T) {
+	tests := []struct {
+		version      string
+		major, minor int
+		ok           bool
+		async        bool
+	}{
+		{"go1.22.3", 1, 22, true, true},
+		{"go1.21rc2", 1, 21, true, true},
+		{"go1.22-abcdef", 1, 22, true, true},
+		{"devel go1.23-abcdef Tue Jan 2 15:04:05 2024 +0000", 1, 23, true, true},
+		{"go1.13", 1, 13, true, false},
+		{"go1.14", 1, 14, true, true},
+		{"go2.0", 2, 0, true, true},
+		// 老的 devel 版本没有版本号, 当成最新的
+		{"devel +abc Tue Jan 2 15:04:05 2024 +0000", 0, 0, false, true},
+		{"", 0, 0, false, true},
+		{"go1", 0, 0, false, true},
+		{"go1.x", 0, 0, false, true},
+	}
+	for _, tt := range tests {
+		major, minor, ok := parseGoVersion(tt.version)
+		if major != tt.major || minor != tt.minor || ok != tt.ok {
+			t.Errorf("parseGoVersion(%q) = %d, %d, %t; want %d, %d, %t", tt.version, major, minor, ok, tt.major, tt.minor, tt.ok)
+		}
+		if got := asyncPreemptSupported(tt.version); got != tt.async {
+			t.Errorf("asyncPreemptSupported(%q) = %t, want %t", tt.version, got, tt.async)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package schedule
+
+import (
+	"encoding/json"
+	"runtime"
+	"time"
+)
+
+// Report 是所有演示结果的公共接口, JSON 里会带上 demo 和 go_version 两个字段, 方便下游区分是哪个演示, 哪个版本跑出来的
+type Report interface {
+	json.Marshaler
+	// 演示的名字, 即 JSON 里的 demo 字段
+	Demo() string
+}
+
+var (
+	_ Report = StarvationReport{}
+	_ Report = StealStats{}
+	_ Report = PreemptReport{}
+	_ Report = SysmonReport{}
+	_ Report = SchedSummary{}
+	_ Report = PlacementReport{}
+	_ Report = ContentionResult{}
+	_ Report = Histogram{}
+	_ Report = SpinReport{}
+	_ Report = HandoffReport{}
+	_ Report = CancelReport{}
+	_ Report = ThreadReport{}
+	_ Report = RunnextReport{}
+	_ Report = YieldReport{}
+	_ Report = ThreadLocalReport{}
+	_ Report = BackpressureReport{}
+	_ Report = UnstealableReport{}
+)
+
+// jsonDuration 是 time.Duration 的 JSON 格式, 同时给出纳秒数和 time.Duration.String()
+type jsonDuration struct {
+	Nanos  int64  `json:"ns"`
+	String string `json:"string"`
+}
+
+func newJSONDuration(d time.Duration) jsonDuration {
+	return jsonDuration{Nanos: int64(d), String: d.String()}
+}
+
+// marshalReport 把 v 序列化之后加上 demo 和 go_version 字段
+func marshalReport(demo string, v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	if fields["demo"], err = json.Marshal(demo); err != nil {
+		return nil, err
+	}
+	if fields["go_version"], err = json.Marshal(runtime.Version()); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// Demo 实现 Report
+func (r StarvationReport) Demo() string { return "starvation" }
+
+// MarshalJSON 实现 Report
+func (r StarvationReport) MarshalJSON() ([]byte, error) {
+	type plain StarvationReport
+	return marshalReport(r.Demo(), plain(r))
+}
+
+// Demo 实现 Report
+func (s StealStats) Demo() string { return "worksteal" }
+
+// MarshalJSON 实现 Report
+func (s StealStats) MarshalJSON() ([]byte, error) {
+	type plain StealStats
+	return marshalReport(s.Demo(), plain(s))
+}
+
+// Demo 实现 Report
+func (r PreemptReport) Demo() string { return "preempt" }
+
+// MarshalJSON 实现 Report
+func (r PreemptReport) MarshalJSON() ([]byte, error) {
+	type plain PreemptReport
+	return marshalReport(r.Demo(), plain(r))
+}
+
+// Demo 实现 Report
+func (r SysmonReport) Demo() string { return "sysmon" }
+
+// MarshalJSON 实现 Report
+func (r SysmonReport) MarshalJSON() ([]byte, error) {
+	type plain SysmonReport
+	return marshalReport(r.Demo(), struct {
+		plain
+		MaxStall jsonDuration `json:"max_stall"`
+	}{plain(r), newJSONDuration(r.MaxStall)})
+}
+
+// UnmarshalJSON 解析 MarshalJSON 的输出
+func (r *SysmonReport) UnmarshalJSON(b []byte) error {
+	type plain SysmonReport
+	v := struct {
+		*plain
+		MaxStall jsonDuration `json:"max_stall"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	r.MaxStall = time.Duration(v.MaxStall.Nanos)
+	return nil
+}
+
+// Demo 实现 Report
+func (s SchedSummary) Demo() string { return "trace" }
+
+// MarshalJSON 实现 Report
+func (s SchedSummary) MarshalJSON() ([]byte, error) {
+	type plain SchedSummary
+	return marshalReport(s.Demo(), plain(s))
+}
+
+// Demo 实现 Report
+func (r PlacementReport) Demo() string { return "placement" }
+
+// MarshalJSON 实现 Report
+func (r PlacementReport) MarshalJSON() ([]byte, error) {
+	type plain PlacementReport
+	return marshalReport(r.Demo(), plain(r))
+}
+
+// Demo 实现 Report
+func (r ContentionResult) Demo() string { return "contention" }
+
+// MarshalJSON 实现 Report
+func (r ContentionResult) MarshalJSON() ([]byte, error) {
+	type plain ContentionResult
+	return marshalReport(r.Demo(), plain(r))
+}
+
+// Demo 实现 Report
+func (h Histogram) Demo() string { return "latency" }
+
+// MarshalJSON 实现 Report, 额外输出 p50 和 p99
+func (h Histogram) MarshalJSON() ([]byte, error) {
+	type plain Histogram
+	return marshalReport(h.Demo(), struct {
+		plain
+		Max jsonDuration `json:"max"`
+		P50 jsonDuration `json:"p50"`
+		P99 jsonDuration `json:"p99"`
+	}{plain(h), newJSONDuration(h.Max), newJSONDuration(h.P50()), newJSONDuration(h.P99())})
+}
+
+// UnmarshalJSON 解析 MarshalJSON 的输出, p50 和 p99 是算出来的, 忽略
+func (h *Histogram) UnmarshalJSON(b []byte) error {
+	type plain Histogram
+	v := struct {
+		*plain
+		Max jsonDuration `json:"max"`
+	}{plain: (*plain)(h)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	h.Max = time.Duration(v.Max.Nanos)
+	return nil
+}
+
+// Demo 实现 Report
+func (r SpinReport) Demo() string { return "spinning" }
+
+// MarshalJSON 实现 Report
+func (r SpinReport) MarshalJSON() ([]byte, error) {
+	type plain SpinReport
+	return marshalReport(r.Demo(), plain(r))
+}
+
+// Demo 实现 Report
+func (r HandoffReport) Demo() string { return "handoff" }
+
+// MarshalJSON 实现 Report
+func (r HandoffReport) MarshalJSON() ([]byte, error) {
+	type plain HandoffReport
+	return marshalReport(r.Demo(), plain(r))
+}
+
+// Demo 实现 Report
+func (r CancelReport) Demo() string { return "cancel" }
+
+// MarshalJSON 实现 Report
+func (r CancelReport) MarshalJSON() ([]byte, error) {
+	type plain CancelReport
+	return marshalReport(r.Demo(), plain(r))
+}
+
+// Demo 实现 Report
+func (r ThreadReport) Demo() string { return "syscall_threads" }
+
+// MarshalJSON 实现 Report
+func (r ThreadReport) MarshalJSON() ([]byte, error) {
+	type plain ThreadReport
+	return marshalReport(r.Demo(), plain(r))
+}
+
+// Demo 实现 Report
+func (r RunnextReport) Demo() string { return "runnext" }
+
+// MarshalJSON 实现 Report
+func (r RunnextReport) MarshalJSON() ([]byte, error) {
+	type plain RunnextReport
+	return marshalReport(r.Demo(), plain(r))
+}
+
+// Demo 实现 Report
+func (r YieldReport) Demo() string { return "yield" }
+
+// MarshalJSON 实现 Report
+func (r YieldReport) MarshalJSON() ([]byte, error) {
+	type plain YieldReport
+	return marshalReport(r.Demo(), plain(r))
+}
+
+// Demo 实现 Report
+func (r ThreadLocalReport) Demo() string { return "threadlocal" }
+
+// MarshalJSON 实现 Report
+func (r ThreadLocalReport) MarshalJSON() ([]byte, error) {
+	type plain ThreadLocalReport
+	return marshalReport(r.Demo(), plain(r))
+}
+
+// Demo 实现 Report
+func (r BackpressureReport) Demo() string { return "backpressure" }
+
+// MarshalJSON 实现 Report
+func (r BackpressureReport) MarshalJSON() ([]byte, error) {
+	type plain BackpressureReport
+	return marshalReport(r.Demo(), plain(r))
+}
+
+// Demo 实现 Report
+func (r UnstealableReport) Demo() string { return "unstealable" }
+
+// MarshalJSON 实现 Report
+func (r UnstealableReport) MarshalJSON() ([]byte, error) {
+	type plain UnstealableReport
+	return marshalReport(r.Demo(), plain(r))
+}
@@ -0,0 +1,105 @@
+package schedule
+
+import (
+	"encoding/json"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func sampleHistogram() Histogram {
+	h, _ := newHistogram(nil)
+	for _, d := range []time.Duration{3 * time.Microsecond, 40 * time.Microsecond, 700 * time.Microsecond, 150 * time.Millisecond} {
+		h.observe(d)
+	}
+	return h
+}
+
+// 每种 Report 一个字段都不为零的样本
+func sampleReports() []Report {
+	return []Report{
+		StarvationReport{Increments: 42, ObserverRuns: 3, Fairness: 0.75},
+		StealStats{PerGoroutineP: []int{0, 1, 1}, PerPGoroutines: []int64{1, 2}, Stolen: 2, Imbalance: 2.0 / 3, Fairness: 0.9, PeakGoroutines: 5},
+		PreemptReport{ObserverRuns: 7, GoVersion: runtime.Version(), AsyncExpected: true},
+		SysmonReport{MaxStall: 12345678 * time.Nanosecond, PreemptionLikely: true},
+		SchedSummary{GoroutineCreated: 2, Blocks: 3, Preemptions: 1, ByGoroutine: map[int64]GoroutineSched{17: {Blocks: 3, Preemptions: 1}}},
+		PlacementReport{LocalQueueMax: 256, GlobalQueueSeen: true},
+		ContentionResult{ChanNanos: 1, MutexNanos: 2, ChanFair: 0.1, MutexFair: 0.2, ChanCount: 8, MutexCount: 8},
+		sampleHistogram(),
+		SpinReport{MaxSpinning: 2, Samples: []int{0, 2, 1}},
+		HandoffReport{UnbufferedNanos: 10, BufferedNanos: 20},
+		CancelReport{Depth: 4, PropagationNanos: 1000, AllCancelled: true},
+		ThreadReport{ThreadsBefore: 5, ThreadsAfter: 9, RawSyscall: true},
+		RunnextReport{SpawnOrder: []int{0, 1, 2}, StartOrder: []int{2, 0, 1}, RunnextObserved: true},
+		YieldReport{GoschedResumed: true, GoexitRanDefers: true},
+		ThreadLocalReport{LockedStayedOnOneThread: true, UnlockedMigrated: true, LockedTids: []int{1, 1}, UnlockedTids: []int{1, 2}},
+		BackpressureReport{ConsumerRuns: 99, ProducerBlocked: true},
+		UnstealableReport{IdleProcsObserved: 3, BlockedGoroutines: 16},
+	}
+}
+
+func TestReportJSONRoundTrip(t *testing.T) {
+	for _, r := range sampleReports() {
+		t.Run(r.Demo(), func(t *testing.T) {
+			b, err := json.Marshal(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(b, &fields); err != nil {
+				t.Fatal(err)
+			}
+			var demo, version string
+			json.Unmarshal(fields["demo"], &demo)
+			json.Unmarshal(fields["go_version"], &version)
+			if demo != r.Demo() {
+				t.Errorf("demo = %q, want %q", demo, r.Demo())
+			}
+			if version != runtime.Version() {
+				t.Errorf("go_version = %q, want %q", version, runtime.Version())
+			}
+
+			back := reflect.New(reflect.TypeOf(r))
+			if err := json.Unmarshal(b, back.Interface()); err != nil {
+				t.Fatal(err)
+			}
+			if got := back.Elem().Interface(); !reflect.DeepEqual(got, r) {
+				t.Errorf("round trip:\n got %+v\nwant %+v\njson %s", got, r, b)
+			}
+		})
+	}
+}
+
+func TestReportJSONDurations(t *testing.T) {
+	check := func(t *testing.T, raw json.RawMessage, want time.Duration) {
+		t.Helper()
+		var d jsonDuration
+		if err := json.Unmarshal(raw, &d); err != nil {
+			t.Fatalf("%s: %v", raw, err)
+		}
+		if d.Nanos != int64(want) || d.String != want.String() {
+			t.Errorf("duration = %s, want {ns: %d, string: %q}", raw, int64(want), want.String())
+		}
+	}
+
+	s := SysmonReport{MaxStall: 23*time.Millisecond + 456*time.Microsecond}
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]json.RawMessage
+	json.Unmarshal(b, &fields)
+	check(t, fields["max_stall"], s.MaxStall)
+
+	h := sampleHistogram()
+	if b, err = json.Marshal(h); err != nil {
+		t.Fatal(err)
+	}
+	fields = nil
+	json.Unmarshal(b, &fields)
+	check(t, fields["max"], h.Max)
+	check(t, fields["p50"], h.P50())
+	check(t, fields["p99"], h.P99())
+}
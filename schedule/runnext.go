@@ -0,0 +1,53 @@
+package schedule
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// runnextSpawn 是 RunnextDemo 创建的G的个数, 远小于本地队列的256, 不会溢出到全局队列
+const runnextSpawn = 8
+
+// RunnextReport 是 RunnextDemo 的结果
+type RunnextReport struct {
+	// 创建的顺序, 就是 0..runnextSpawn-1
+	SpawnOrder []int `json:"spawn_order"`
+	// 实际开始运行的顺序
+	StartOrder []int `json:"start_order"`
+	// 最后创建的G是不是第一个运行的
+	RunnextObserved bool `json:"runnext_observed"`
+}
+
+// RunnextDemo 在 GOMAXPROCS(1) 下按顺序创建几个G, 然后阻塞让出P, 记录这些G实际开始运行的顺序
+//
+// P的本地队列并不是单纯的FIFO: newproc 调用 runqput(pp, newg, true) 把新的G放到 p.runnext,
+// 原来在 runnext 里的G被踢到队尾. 所以最后创建的G会第一个运行, 其余的按创建顺序运行, 例如 7 0 1 2 3 4 5 6.
+// runnext 只有一个位置, 所以最多只有一个G"插队"
+func RunnextDemo() RunnextReport {
+	prev := runtime.GOMAXPROCS(1)
+	defer runtime.GOMAXPROCS(prev)
+
+	var (
+		seq   int32
+		start = make([]int, runnextSpawn)
+		wg    sync.WaitGroup
+	)
+	r := RunnextReport{SpawnOrder: make([]int, runnextSpawn)}
+
+	wg.Add(runnextSpawn)
+	for i := 0; i < runnextSpawn; i++ {
+		r.SpawnOrder[i] = i
+		go func(i int) {
+			defer wg.Done()
+			// 第几个开始运行的
+			start[atomic.AddInt32(&seq, 1)-1] = i
+		}(i)
+	}
+	// 当前G阻塞, 让出P, 调度器从 runnext 开始取
+	wg.Wait()
+
+	r.StartOrder = start
+	r.RunnextObserved = start[0] == r.SpawnOrder[runnextSpawn-1]
+	return r
+}
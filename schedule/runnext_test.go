@@ -0,0 +1,38 @@
+package schedule
+
+import (
+	"sort"
+	"testing"
+)
+
+// runnext 只有一个位置, 除了最后创建的G插到最前面以外, 其他G应该按创建顺序开始运行
+func TestRunnextDemo(t *testing.T) {
+	LeakCheck(t)
+	if raceEnabled {
+		// -race 的时候 runtime 的 randomizeScheduler 是 true, runqput 会随机不放 runnext, 顺序是打乱的
+		t.Skip("the race detector randomizes run queue order")
+	}
+	r := RunnextDemo()
+
+	sorted := append([]int(nil), r.StartOrder...)
+	sort.Ints(sorted)
+	for i, v := range sorted {
+		if v != r.SpawnOrder[i] {
+			t.Fatalf("StartOrder %v is not a permutation of SpawnOrder %v", r.StartOrder, r.SpawnOrder)
+		}
+	}
+
+	last := r.SpawnOrder[len(r.SpawnOrder)-1]
+	rest := make([]int, 0, len(r.StartOrder))
+	for _, v := range r.StartOrder {
+		if v != last {
+			rest = append(rest, v)
+		}
+	}
+	if !sort.IntsAreSorted(rest) {
+		t.Errorf("StartOrder = %v, more than one goroutine jumped the queue", r.StartOrder)
+	}
+	if !r.RunnextObserved {
+		t.Errorf("StartOrder = %v, want goroutine %d (in runnext) to start first", r.StartOrder, last)
+	}
+}
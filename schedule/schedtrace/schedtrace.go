@@ -0,0 +1,145 @@
+// Package schedtrace 解析 GODEBUG=schedtrace=X 输出到 stderr 的调度器状态行
+//
+//	SCHED 1009ms: gomaxprocs=4 idleprocs=3 threads=7 spinningthreads=0 idlethreads=3 runqueue=0 [0 0 0 1]
+//
+// 新版本的 runtime 还会输出 needspinning, schedticks=[...] 等字段, 不认识的字段会被忽略.
+// 同一个 stderr 里的 gctrace, scvg 等其他行也会被忽略, 只有 SCHED 开头但是格式不对的行才会报错
+package schedtrace
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SchedTraceLine 是一行 SCHED 输出
+type SchedTraceLine struct {
+	// 进程启动之后的毫秒数
+	Ms              int
+	Gomaxprocs      int
+	IdleProcs       int
+	Threads         int
+	SpinningThreads int
+	IdleThreads     int
+	// 全局队列的长度
+	RunQueue int
+	// 每个P本地队列的长度, scheddetail=1 的时候没有这一项
+	PerPRunQueues []int
+}
+
+// Parse 读取 r 里所有的 SCHED 行
+func Parse(r io.Reader) ([]SchedTraceLine, error) {
+	var lines []SchedTraceLine
+
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		text := sc.Text()
+		if !strings.HasPrefix(text, "SCHED ") {
+			continue
+		}
+		line, err := ParseLine(text)
+		if err != nil {
+			return lines, fmt.Errorf("schedtrace: line %d: %v", n, err)
+		}
+		lines = append(lines, line)
+	}
+	return lines, sc.Err()
+}
+
+// 每一行都必须有的字段
+var requiredFields = []string{"gomaxprocs", "idleprocs", "threads", "idlethreads", "runqueue"}
+
+// ParseLine 解析一行 SCHED 输出
+func ParseLine(text string) (SchedTraceLine, error) {
+	var l SchedTraceLine
+
+	rest := strings.TrimPrefix(text, "SCHED ")
+	colon := strings.IndexByte(rest, ':')
+	if colon < 0 || !strings.HasSuffix(rest[:colon], "ms") {
+		return l, fmt.Errorf("missing timestamp in %q", text)
+	}
+	ms, err := strconv.Atoi(strings.TrimSuffix(rest[:colon], "ms"))
+	if err != nil {
+		return l, fmt.Errorf("bad timestamp in %q", text)
+	}
+	l.Ms = ms
+
+	fields := map[string]int{}
+	tokens := strings.Fields(rest[colon+1:])
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+
+		// 形如 [0 0 0 1] 或者 [ 2 ], 可能被空格拆成好几个 token
+		if strings.HasPrefix(tok, "[") {
+			list, next, err := parseList(tok, tokens, i)
+			if err != nil {
+				return l, fmt.Errorf("%v in %q", err, text)
+			}
+			if l.PerPRunQueues == nil {
+				l.PerPRunQueues = list
+			}
+			i = next
+			continue
+		}
+
+		eq := strings.IndexByte(tok, '=')
+		if eq <= 0 {
+			return l, fmt.Errorf("unexpected token %q in %q", tok, text)
+		}
+		key, val := tok[:eq], tok[eq+1:]
+		if strings.HasPrefix(val, "[") {
+			// schedticks=[...] 之类的, 不关心, 跳过
+			_, next, err := parseList(val, tokens, i)
+			if err != nil {
+				return l, fmt.Errorf("%v in %q", err, text)
+			}
+			i = next
+			continue
+		}
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			// gcwaiting=false 这种不是数字的字段
+			continue
+		}
+		fields[key] = n
+	}
+
+	for _, k := range requiredFields {
+		if _, ok := fields[k]; !ok {
+			return l, fmt.Errorf("missing %s in %q", k, text)
+		}
+	}
+	l.Gomaxprocs = fields["gomaxprocs"]
+	l.IdleProcs = fields["idleprocs"]
+	l.Threads = fields["threads"]
+	l.SpinningThreads = fields["spinningthreads"]
+	l.IdleThreads = fields["idlethreads"]
+	l.RunQueue = fields["runqueue"]
+	return l, nil
+}
+
+// parseList 解析一个从 first(也就是 tokens[i] 里 '[' 开始的部分)开始的 [...] 列表, 返回列表和 ']' 所在 token 的下标
+func parseList(first string, tokens []string, i int) ([]int, int, error) {
+	list := []int{}
+	for j := i; j < len(tokens); j++ {
+		tok := tokens[j]
+		if j == i {
+			tok = strings.TrimPrefix(first, "[")
+		}
+		end := strings.HasSuffix(tok, "]")
+		tok = strings.TrimSuffix(tok, "]")
+		for _, f := range strings.Fields(tok) {
+			n, err := strconv.Atoi(f)
+			if err != nil {
+				return nil, j, fmt.Errorf("bad list element %q", f)
+			}
+			list = append(list, n)
+		}
+		if end {
+			return list, j, nil
+		}
+	}
+	return nil, len(tokens), fmt.Errorf("unterminated list")
+}
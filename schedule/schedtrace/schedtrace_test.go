@@ -0,0 +1,64 @@
+package schedtrace
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 前5行是 go1.27 下 GODEBUG=schedtrace=1,gctrace=1,scavtrace=1 实际抓到的输出, 后面是单P, 旧版本(scvg, 没有空格的列表)和 scheddetail=1 的格式
+const captured = `SCHED 0ms: gomaxprocs=4 idleprocs=0 threads=5 spinningthreads=2 needspinning=0 idlethreads=1 runqueue=0 [ 0 0 0 0 ] schedticks=[ 4 1 0 6 ]
+scav 0 KiB work (bg), 0 KiB work (eager), 7504 KiB now, 53% util
+SCHED 3ms: gomaxprocs=4 idleprocs=0 threads=5 spinningthreads=1 needspinning=0 idlethreads=0 runqueue=1 [ 0 0 0 0 ] schedticks=[ 4 1 1 7 ]
+gc 1 @0.000s 18%: 0.026+0.27+0.076 ms clock, 0.026+0.14/0.001/0+0.076 ms cpu, 4->4->2 MB, 4 MB goal, 0 MB stacks, 0 MB globals, 4 P
+SCHED 8ms: gomaxprocs=4 idleprocs=0 threads=5 spinningthreads=1 needspinning=0 idlethreads=1 runqueue=0 [ 1 0 0 0 ] schedticks=[ 4 20855 1 8 ]
+SCHED 0ms: gomaxprocs=1 idleprocs=0 threads=3 spinningthreads=0 needspinning=1 idlethreads=1 runqueue=0 [ 2 ] schedticks=[ 0 ]
+scvg: 0 MB released
+scvg: inuse: 3, idle: 60, sys: 63, released: 0, consumed: 63 (MB)
+SCHED 1009ms: gomaxprocs=4 idleprocs=3 threads=7 spinningthreads=0 idlethreads=3 runqueue=0 [0 0 0 1]
+SCHED 2013ms: gomaxprocs=2 idleprocs=2 threads=4 spinningthreads=0 needspinning=0 idlethreads=2 runqueue=0 gcwaiting=false nmidlelocked=0 stopwait=0 sysmonwait=false
+  P0: status=0 schedtick=3 syscalltick=0 m=nil runqsize=0 gfreecnt=0 timerslen=0
+`
+
+func TestParse(t *testing.T) {
+	got, err := Parse(strings.NewReader(captured))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SchedTraceLine{
+		{Ms: 0, Gomaxprocs: 4, IdleProcs: 0, Threads: 5, SpinningThreads: 2, IdleThreads: 1, RunQueue: 0, PerPRunQueues: []int{0, 0, 0, 0}},
+		{Ms: 3, Gomaxprocs: 4, IdleProcs: 0, Threads: 5, SpinningThreads: 1, IdleThreads: 0, RunQueue: 1, PerPRunQueues: []int{0, 0, 0, 0}},
+		{Ms: 8, Gomaxprocs: 4, IdleProcs: 0, Threads: 5, SpinningThreads: 1, IdleThreads: 1, RunQueue: 0, PerPRunQueues: []int{1, 0, 0, 0}},
+		{Ms: 0, Gomaxprocs: 1, IdleProcs: 0, Threads: 3, SpinningThreads: 0, IdleThreads: 1, RunQueue: 0, PerPRunQueues: []int{2}},
+		{Ms: 1009, Gomaxprocs: 4, IdleProcs: 3, Threads: 7, SpinningThreads: 0, IdleThreads: 3, RunQueue: 0, PerPRunQueues: []int{0, 0, 0, 1}},
+		// scheddetail=1 没有本地队列, 详情在后面的 P0: 行里
+		{Ms: 2013, Gomaxprocs: 2, IdleProcs: 2, Threads: 4, SpinningThreads: 0, IdleThreads: 2, RunQueue: 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseLineErrors(t *testing.T) {
+	for _, text := range []string{
+		"SCHED oops: gomaxprocs=1",
+		"SCHED 12: gomaxprocs=1 idleprocs=0 threads=3 idlethreads=1 runqueue=0",
+		"SCHED 12ms: gomaxprocs=1 idleprocs=0 threads=3 idlethreads=1",
+		"SCHED 12ms: gomaxprocs=1 idleprocs=0 threads=3 idlethreads=1 runqueue=0 [ 1 2",
+		"SCHED 12ms: gomaxprocs=1 idleprocs=0 threads=3 idlethreads=1 runqueue=0 [ x ]",
+		"SCHED 12ms: gomaxprocs=1 idleprocs=0 threads=3 idlethreads=1 runqueue=0 schedticks=[ 1",
+		"SCHED 12ms: gomaxprocs=1 idleprocs=0 threads=3 idlethreads=1 runqueue=0 garbage",
+	} {
+		if l, err := ParseLine(text); err == nil {
+			t.Errorf("ParseLine(%q) = %+v, want error", text, l)
+		}
+	}
+}
+
+func TestParseReportsLineNumber(t *testing.T) {
+	in := "gc 1 @0.000s 0%: ...\nSCHED 1ms: gomaxprocs=1\n"
+	lines, err := Parse(strings.NewReader(in))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Parse = %v, %v; want an error mentioning line 2", lines, err)
+	}
+}
@@ -0,0 +1,83 @@
+package schedule
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chidaren/read-go-source-code/schedule/schedtrace"
+)
+
+// spinWindow 是子进程制造 burst 的时间
+const spinWindow = 200 * time.Millisecond
+
+// SpinReport 是 SpinningThreads 的结果
+type SpinReport struct {
+	// 采样到的最大 spinningthreads
+	MaxSpinning int `json:"max_spinning"`
+	// 每条 schedtrace 一个采样, sysmon 空闲的时候会拉长检查间隔, 所以不一定是每毫秒一个
+	Samples []int `json:"samples"`
+}
+
+// SpinningThreads 在子进程里以 GOMAXPROCS(procs) 每毫秒创建 burstGoroutines 个很快就结束的G, 通过 schedtrace 观察自旋的M
+//
+// 有新的G但是没有空闲的M在自旋的时候, wakep 会唤醒一个M自旋找活干, 找不到就休眠. 所以有 burst 的时候 spinningthreads 会时不时大于0,
+// burstGoroutines 为0的时候没有活干, 自旋的M很快都会休眠. 不管有没有 burst, 都是 spinWindow 之后返回.
+// procs 比CPU核数多也照样用, 空闲的P越多, 越容易看到自旋的M
+func SpinningThreads(procs int, burstGoroutines int) (SpinReport, error) {
+	var r SpinReport
+
+	opts := Options{Procs: procs, Duration: spinWindow}
+	if err := opts.Validate(); err != nil && !IsWarning(err) {
+		return r, err
+	}
+	if err := validateMin("burstGoroutines", burstGoroutines, 0); err != nil {
+		return r, err
+	}
+
+	// 不用 Validate 按 NumCPU 改过的 Procs
+	cmd, err := childCommand("spinning", fmt.Sprintf("%d,%d", procs, burstGoroutines), "schedtrace=1")
+	if err != nil {
+		return r, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return r, fmt.Errorf("schedule: spinning child: %v: %s", err, stderr.Bytes())
+	}
+
+	lines, err := schedtrace.Parse(&stderr)
+	if err != nil {
+		return r, err
+	}
+	for _, l := range lines {
+		r.Samples = append(r.Samples, l.SpinningThreads)
+		if l.SpinningThreads > r.MaxSpinning {
+			r.MaxSpinning = l.SpinningThreads
+		}
+	}
+	return r, nil
+}
+
+// spinningChild 的参数是 "procs,burstGoroutines"
+func spinningChild(arg string) {
+	var procs, burst int
+	if i := strings.IndexByte(arg, ','); i >= 0 {
+		procs, _ = strconv.Atoi(arg[:i])
+		burst, _ = strconv.Atoi(arg[i+1:])
+	}
+	if procs > 0 {
+		runtime.GOMAXPROCS(procs)
+	}
+
+	deadline := time.Now().Add(spinWindow)
+	for time.Now().Before(deadline) {
+		for i := 0; i < burst; i++ {
+			go func() {}()
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
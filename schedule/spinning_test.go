@@ -0,0 +1,50 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// 自旋的M一般只持续几微秒, 1ms 一次的采样不一定能碰上, 所以这里不要求 MaxSpinning > 0
+func TestSpinningThreads(t *testing.T) {
+	LeakCheck(t)
+	const procs = 4
+	r, err := SpinningThreads(procs, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Samples) == 0 {
+		t.Fatal("no schedtrace samples")
+	}
+	// findRunnable 里 2*nmspinning >= gomaxprocs-npidle 的时候就不再增加自旋的M
+	for _, n := range r.Samples {
+		if n < 0 || n > procs {
+			t.Errorf("spinningthreads = %d outside [0, %d], samples %v", n, procs, r.Samples)
+		}
+	}
+}
+
+// 没有 burst 的时候也要在 spinWindow 之后返回, 大部分采样都没有自旋的M
+func TestSpinningThreadsNoBurst(t *testing.T) {
+	LeakCheck(t)
+	start := time.Now()
+	r, err := SpinningThreads(4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*spinWindow {
+		t.Errorf("took %v with no bursts", elapsed)
+	}
+	if len(r.Samples) == 0 {
+		t.Fatal("no schedtrace samples")
+	}
+	spinning := 0
+	for _, n := range r.Samples {
+		if n > 0 {
+			spinning++
+		}
+	}
+	if 2*spinning > len(r.Samples) {
+		t.Errorf("%d of %d samples have spinning Ms with no work: %v", spinning, len(r.Samples), r.Samples)
+	}
+}
@@ -0,0 +1,205 @@
+// Package schedule 把原来 schedule/gmp.go 里的调度演示整理成可以调用, 可以断言的函数, cmd/gmp 是原来的 main
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+
+一般情况下, 如果G比较少的时候(这时候G会放在P的局部队列, 不会放到全局队列), 一般不会创建多个P(或者我们指定了最多一个P), 那么这些G基本上都是加到P的一个FIFO队列里, 如果头部的G, 哪一个死循环了(没有io, channel 调用, 文件读写, time.Sleep 等操作)
+那么再这个P上, 队列里后面的G, 不会有任何机会得到调用(除非有多个P, 某一个P 上已经没有G了,M会从其他P上偷一些G, 如果偷不到, 会从全局队列获取到局部队列来执行)
+
+G是抢占式调度, 没有时间片的概念,M对它绑定的P是有时间片的概念的, 但是如果只有一个P, 那么就相当于所有的G 都是抢占式调用, 如果一个G没有因为io或者channel 被阻塞, 那么基本上
+它会一直占用着这个P
+
+上面说的是 go1.14 之前的情况. go1.14 之后 sysmon 发现一个G连续运行超过10ms, 会通过信号异步抢占它(见 preempt.go), 即使是没有函数调用的死循环,
+单P的时候其他G大概每10~20ms也能得到一次调度, 所以现在已经看不到完全饿死的现象了, 除非 GODEBUG=asyncpreemptoff=1
+
+另外P的局部队列也不是严格的FIFO, 新创建的G会先放到 p.runnext, 比队列里的G先运行(见 runnext.go), 所以A, B谁先运行取决于谁后创建
+
+*/
+
+// StarvationReport 是 StarvationDemo 结束时的统计
+type StarvationReport struct {
+	// A 累加的次数
+	Increments int64 `json:"increments"`
+	// B 打印的次数
+	ObserverRuns int64 `json:"observer_runs"`
+	// 按秒算 A 和 B 各自得到调度的次数的 Jain 公平性指数: A 每秒都在跑, B 如果被饿死就接近 1/2, 正常是 1
+	Fairness float64 `json:"fairness"`
+}
+
+// StarvationDemo 就是原来 gmp.go 的 main, 每秒打印一次 A 累加的次数, 持续 opts.Duration 或者直到 ctx 被取消
+//
+// 如果B先于A被初始化, 那么会先输出0, 然后阻塞, A被调用, 一直占着P.
+// 返回前 A, B 都已经退出, GOMAXPROCS 也会恢复. opts 不合法的时候直接返回 opts.Validate() 的错误
+//
+// GODEBUG=asyncpreemptoff=1 并且 Procs 是 1 的时候调用方和信号处理的G都拿不到P, ctx 被取消也没法通知 A,
+// 只能等 A 自己发现到了 opts.Duration 退出
+func StarvationDemo(ctx context.Context, opts Options) (StarvationReport, error) {
+	if err := opts.Validate(); err != nil && !IsWarning(err) {
+		return StarvationReport{}, err
+	}
+
+	var (
+		count int64
+		runs  int64
+		wg    sync.WaitGroup
+	)
+
+	prev := runtime.GOMAXPROCS(opts.Procs)
+	defer runtime.GOMAXPROCS(prev)
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	// nanotime 和 ctx 的 deadline 不是同一个时钟, 换算成相对时间. WithTimeout 之后 ctx 一定有 deadline
+	d, _ := ctx.Deadline()
+	deadline := nanotime() + int64(time.Until(d))
+	var stop int32
+
+	out := opts.output()
+	ev := events{logger: opts.logger(), demo: "starvation"}
+	wg.Add(2)
+	start := ev.start()
+
+	// A
+	ev.log(ctx, eventSpawned, 0)
+	go func() {
+		defer wg.Done()
+		defer ev.log(ctx, eventExited, 0)
+		// 不能用 select 检查 ctx, 非阻塞的 select 会调用 runtime.selectnbrecv, 给了调度器一个协作式抢占点.
+		// 和 RunStarvationYield 一样只读 stop 标记, 每 clockCheckEvery 次用 nosplit 的 nanotime 看一下 deadline
+		for i := int64(1); atomic.LoadInt32(&stop) == 0; i++ {
+			// 如果只有一个逻辑P, 则一旦调用此G, 则不会有任何机会让出,　除非显示调用　runtime.Gosched()
+			// A 写 B 读, 必须用原子操作, 否则是 data race. atomic.AddInt64 会被编译成一条指令, 不是函数调用, 不影响上面说的调度行为
+			atomic.AddInt64(&count, 1)
+			if opts.Yield {
+				ev.log(ctx, eventYield, 0)
+				runtime.Gosched()
+			}
+			if i%clockCheckEvery == 0 && nanotime() >= deadline {
+				break
+			}
+		}
+		ev.log(ctx, eventDeadline, 0)
+	}()
+
+	// B
+	ev.log(ctx, eventSpawned, 1)
+	go func() {
+		defer wg.Done()
+		defer ev.log(ctx, eventExited, 1)
+		tick := time.NewTicker(time.Second)
+		defer tick.Stop()
+		for {
+			// 如果一个P的时候，一旦死循环的G被调用，则此G不会再有任何机会被调用, 如果此G后调用, 则会加到p 的尾部, 上面的G会先被执行, 此G不会有任何机会被调用
+			fmt.Fprintln(out, atomic.LoadInt64(&count))
+			runs++
+			select {
+			case <-ctx.Done():
+				ev.log(ctx, eventDeadline, 1)
+				return
+			case <-tick.C:
+				// A 饿死 B 的时候 tick 和 ctx 在 A 退出之后同时就绪, 这时候打印的是过期的 tick, 不算 B 得到了调度.
+				// ctx 是 time.AfterFunc 在另一个G里取消的, B 可能比它先运行, 所以还要直接看 deadline
+				if ctx.Err() != nil || nanotime() >= deadline {
+					ev.log(ctx, eventDeadline, 1)
+					return
+				}
+			}
+		}
+	}()
+
+	<-ctx.Done()
+	atomic.StoreInt32(&stop, 1)
+	wg.Wait()
+	// B 第一次打印不需要等, 所以预期次数是整秒数加1
+	expected := int64(time.Since(start)/time.Second) + 1
+	return StarvationReport{
+		Increments:   atomic.LoadInt64(&count),
+		ObserverRuns: runs,
+		Fairness:     JainFairness([]int64{expected, runs}),
+	}, nil
+}
+
+// observeInterval 是观察者 B 每次打印之间 sleep 的时间, gmp.go 里是 1s, 这里缩短一点方便测试
+const observeInterval = 10 * time.Millisecond
+
+// RunStarvation 复现 gmp.go 里单P饥饿的场景, ctx 取消后返回
+//
+// starvedObserved 表示 B 在这段时间内执行的次数不到预期的一半(预期次数 = 持续时间 / observeInterval),
+// iterations 是 A 死循环累加的次数. 返回前会把 GOMAXPROCS 恢复成原来的值
+//
+// 注意 go1.14 之后 runtime 会通过信号异步抢占死循环的G, 所以即使 procs=1 也不一定能观察到饥饿,
+// 需要 GODEBUG=asyncpreemptoff=1 才能看到 gmp.go 注释里描述的现象. 这时调用方自己也拿不到P, 没法在 ctx 取消之后通知 A 停下来,
+// 所以 ctx 有 deadline 的时候 A 会自己用 nanotime 检查, 到期就退出. 没有 deadline 的 ctx 在这种情况下不会返回
+func RunStarvation(ctx context.Context, procs int) (starvedObserved bool, iterations int64) {
+	return RunStarvationYield(ctx, procs, nil)
+}
+
+// RunStarvationYield 和 RunStarvation 一样, 但是 A 每次循环都会调用一次 yield (nil 表示不让出),
+// 传入 runtime.Gosched 的话 B 就能正常得到调度
+func RunStarvationYield(ctx context.Context, procs int, yield func()) (starvedObserved bool, iterations int64) {
+	prev := runtime.GOMAXPROCS(procs)
+	defer runtime.GOMAXPROCS(prev)
+
+	var (
+		count int64
+		runs  int64
+		stop  int32
+		done  = make(chan struct{}, 2)
+		gate  StartGate
+	)
+	gate.Add(2)
+
+	// nanotime 和 ctx 的 deadline 不是同一个时钟, 换算成相对时间
+	deadline := int64(math.MaxInt64)
+	if d, ok := ctx.Deadline(); ok {
+		deadline = nanotime() + int64(time.Until(d))
+	}
+
+	// A
+	go func() {
+		defer func() { done <- struct{}{} }()
+		gate.Ready()
+		// 这里只做原子读和 nosplit 的 nanotime, 没有普通的函数调用, 不会产生协作式抢占点
+		for i := int64(1); atomic.LoadInt32(&stop) == 0; i++ {
+			atomic.AddInt64(&count, 1)
+			if yield != nil {
+				yield()
+			}
+			if i%clockCheckEvery == 0 && nanotime() >= deadline {
+				break
+			}
+		}
+	}()
+
+	// B
+	go func() {
+		defer func() { done <- struct{}{} }()
+		gate.Ready()
+		for atomic.LoadInt32(&stop) == 0 {
+			atomic.AddInt64(&runs, 1)
+			time.Sleep(observeInterval)
+		}
+	}()
+
+	gate.Go()
+	start := time.Now()
+	<-ctx.Done()
+	elapsed := time.Since(start)
+	atomic.StoreInt32(&stop, 1)
+	<-done
+	<-done
+
+	expected := int64(elapsed / observeInterval)
+	return atomic.LoadInt64(&runs) < expected/2, atomic.LoadInt64(&count)
+}
@@ -0,0 +1,156 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	starvationWindow = 200 * time.Millisecond
+	// B 每秒打印一次, 2.5s 不饿死的话能打印3次
+	starvationDemoWindow = 2500 * time.Millisecond
+)
+
+// starvationTestChild 的参数是 "procs,yield", 输出 "starvedObserved iterations"
+func starvationTestChild(arg string) {
+	parts := strings.Split(arg, ",")
+	procs, _ := strconv.Atoi(parts[0])
+	var yield func()
+	if parts[1] == "true" {
+		yield = runtime.Gosched
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), starvationWindow)
+	defer cancel()
+	starved, iterations := RunStarvationYield(ctx, procs, yield)
+	fmt.Println(starved, iterations)
+}
+
+// 都在 GODEBUG=asyncpreemptoff=1 的子进程里跑, 否则 procs=1 的时候 sysmon 会异步抢占 A, 看不到饥饿
+func TestRunStarvation(t *testing.T) {
+	LeakCheck(t)
+	tests := []struct {
+		procs       int
+		yield       bool
+		wantStarved bool
+	}{
+		{procs: 1, yield: false, wantStarved: true},
+		{procs: 1, yield: true, wantStarved: false},
+		{procs: 2, yield: false, wantStarved: false},
+		{procs: 2, yield: true, wantStarved: false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("procs=%d/yield=%t", tt.procs, tt.yield), func(t *testing.T) {
+			out := runChild(t, "test-starvation", fmt.Sprintf("%d,%t", tt.procs, tt.yield), "asyncpreemptoff=1")
+			var (
+				starved    bool
+				iterations int64
+			)
+			if _, err := fmt.Sscan(out, &starved, &iterations); err != nil {
+				t.Fatalf("child output %q: %v", out, err)
+			}
+			if starved != tt.wantStarved {
+				t.Errorf("starvedObserved = %t, want %t", starved, tt.wantStarved)
+			}
+			if iterations <= 0 {
+				t.Errorf("iterations = %d, want > 0", iterations)
+			}
+		})
+	}
+}
+
+// starvationDemoTestChild 的参数是 "yield", 以 Procs: 1 跑 StarvationDemo, 输出 "ObserverRuns Increments"
+func starvationDemoTestChild(arg string) {
+	r, err := StarvationDemo(context.Background(), Options{Procs: 1, Duration: starvationDemoWindow, Yield: arg == "true", Output: io.Discard})
+	if err != nil && !IsWarning(err) {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(r.ObserverRuns, r.Increments)
+}
+
+// B 后创建, 在 runnext 上先打印一次, 之后 A 一直占着唯一的P, 直到 A 自己到期退出 B 都没有机会再打印
+func TestStarvationDemoSingleP(t *testing.T) {
+	LeakCheck(t)
+	for _, yield := range []bool{false, true} {
+		t.Run(fmt.Sprintf("yield=%t", yield), func(t *testing.T) {
+			out := runChild(t, "test-starvation-demo", strconv.FormatBool(yield), "asyncpreemptoff=1")
+			var runs, increments int64
+			if _, err := fmt.Sscan(out, &runs, &increments); err != nil {
+				t.Fatalf("child output %q: %v", out, err)
+			}
+			if increments <= 0 {
+				t.Errorf("Increments = %d, want > 0", increments)
+			}
+			if !yield && runs != 1 {
+				t.Errorf("ObserverRuns = %d, want B starved (1)", runs)
+			}
+			if yield && runs < 3 {
+				t.Errorf("ObserverRuns = %d, want B to print every second (>= 3)", runs)
+			}
+		})
+	}
+}
+
+func TestRunStarvationRestoresGOMAXPROCS(t *testing.T) {
+	LeakCheck(t)
+	prev := runtime.GOMAXPROCS(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	RunStarvationYield(ctx, prev+1, runtime.Gosched)
+	if got := runtime.GOMAXPROCS(0); got != prev {
+		t.Errorf("GOMAXPROCS = %d after RunStarvation, want %d", got, prev)
+	}
+}
+
+// 没有开 -race 的时候用 go test -race 只跑这一个测试, 开了的时候直接跑, 有 data race 的话 testing 会让测试失败
+func TestStarvationDemoRace(t *testing.T) {
+	LeakCheck(t)
+	if !raceEnabled {
+		if testing.Short() {
+			t.Skip("rebuilding with -race is slow")
+		}
+		gobin, err := exec.LookPath("go")
+		if err != nil {
+			t.Skip("go command not found")
+		}
+		out, err := exec.Command(gobin, "test", "-race", "-count=1", "-run", "^TestStarvationDemoRace$", ".").CombinedOutput()
+		if strings.Contains(string(out), "DATA RACE") {
+			t.Fatalf("race detected:\n%s", out)
+		}
+		if err != nil {
+			t.Fatalf("go test -race: %v\n%s", err, out)
+		}
+		return
+	}
+
+	// Yield 让 A, B 在单P上也能交错执行, 读写 count 的时候都有机会被检查到
+	for _, procs := range []int{1, 2} {
+		for _, yield := range []bool{false, true} {
+			r, err := StarvationDemo(context.Background(), Options{Procs: procs, Duration: 300 * time.Millisecond, Yield: yield, Output: io.Discard})
+			if err != nil && !IsWarning(err) {
+				t.Fatal(err)
+			}
+			if r.Increments == 0 || r.ObserverRuns == 0 {
+				t.Errorf("procs=%d/yield=%t: %+v, want both goroutines to run", procs, yield, r)
+			}
+		}
+	}
+
+	// 换成原子操作之后单P的饥饿现象不能变, -race 下原子操作是对 race runtime 的调用, 也要确认没有引入抢占点
+	out := runChild(t, "test-starvation-demo", "false", "asyncpreemptoff=1")
+	var runs, increments int64
+	if _, err := fmt.Sscan(out, &runs, &increments); err != nil {
+		t.Fatalf("child output %q: %v", out, err)
+	}
+	if runs != 1 {
+		t.Errorf("single P with asyncpreemptoff: ObserverRuns = %d, want B starved (1)", runs)
+	}
+}
@@ -0,0 +1,81 @@
+package schedule
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// StressSeed 是 Stress 插入 Gosched 用的随机数种子, 失败的时候错误信息里会带上它, 设回同一个值就能重现插入的位置
+var StressSeed int64 = 1
+
+const (
+	// 每次调用 fn 之前以 1/stressYieldOdds 的概率主动让出
+	stressYieldOdds = 4
+	// 最多报告几个 panic 的栈, 其余的只计数
+	maxStressPanics = 8
+)
+
+// Stress 用 parallelism 个G同时跑 fn, 每个G跑 iterations 次, 调用之间按 StressSeed 随机插入 runtime.Gosched() 打乱执行顺序
+//
+//	func TestDemo(t *testing.T) {
+//		schedule.Stress(t, func() { ... }, 8, 1000)
+//	}
+//
+// fn 里的 panic 会被 recover, 不会让整个测试进程崩掉, 所有G结束之后汇总起来和栈一起用 t.Error 报告.
+// 第 i 个G的种子是 StressSeed+i, 所以每个G插入的位置是确定的, 但是G之间真正的交错还是取决于调度器, 不能保证完全重现
+func Stress(t testing.TB, fn func(), parallelism, iterations int) {
+	t.Helper()
+	if parallelism < 1 || iterations < 0 {
+		t.Fatalf("schedule: Stress needs parallelism >= 1 and iterations >= 0, got %d and %d", parallelism, iterations)
+	}
+
+	var (
+		mu     sync.Mutex
+		panics []string
+		total  int
+		wg     sync.WaitGroup
+		gate   StartGate
+	)
+	call := func(g, i int) {
+		defer func() {
+			if v := recover(); v != nil {
+				mu.Lock()
+				total++
+				if len(panics) < maxStressPanics {
+					panics = append(panics, fmt.Sprintf("goroutine %d iteration %d: panic: %v\n%s", g, i, v, debug.Stack()))
+				}
+				mu.Unlock()
+			}
+		}()
+		fn()
+	}
+
+	seed := StressSeed
+	wg.Add(parallelism)
+	gate.Add(parallelism)
+	for g := 0; g < parallelism; g++ {
+		go func(g int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed + int64(g)))
+			gate.Ready()
+			for i := 0; i < iterations; i++ {
+				if rng.Intn(stressYieldOdds) == 0 {
+					runtime.Gosched()
+				}
+				call(g, i)
+			}
+		}(g)
+	}
+	gate.Go()
+	wg.Wait()
+
+	if total > 0 {
+		t.Errorf("schedule: Stress (seed %d): %d of %d calls panicked, first %d:\n%s",
+			seed, total, parallelism*iterations, len(panics), strings.Join(panics, "\n"))
+	}
+}
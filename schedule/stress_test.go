@@ -0,0 +1,94 @@
+package schedule
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeTB 记录 Stress 报告的错误, 不让外面的测试失败. 没有覆盖的方法会因为内嵌的 nil 接口 panic
+type fakeTB struct {
+	testing.TB
+	mu     sync.Mutex
+	errors []string
+	fatal  bool
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.Errorf(format, args...)
+	f.fatal = true
+	runtime.Goexit()
+}
+
+// runStress 在单独的G里调用 Stress, 这样 Fatalf 的 Goexit 不会结束当前测试
+func runStress(fn func(), parallelism, iterations int) *fakeTB {
+	f := &fakeTB{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Stress(f, fn, parallelism, iterations)
+	}()
+	<-done
+	return f
+}
+
+func TestStressAggregatesPanics(t *testing.T) {
+	LeakCheck(t)
+	defer func(seed int64) { StressSeed = seed }(StressSeed)
+	StressSeed = 42
+
+	var calls int64
+	f := runStress(func() {
+		if atomic.AddInt64(&calls, 1)%10 == 0 {
+			panic("boom")
+		}
+	}, 4, 100)
+
+	if calls != 400 {
+		t.Errorf("fn called %d times, want 400 (a panic must not stop the other iterations)", calls)
+	}
+	if len(f.errors) != 1 {
+		t.Fatalf("got %d errors, want one aggregated error: %q", len(f.errors), f.errors)
+	}
+	msg := f.errors[0]
+	for _, want := range []string{"seed 42", "40 of 400 calls panicked", "panic: boom", "stress_test.go"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error missing %q:\n%s", want, msg)
+		}
+	}
+	if n := strings.Count(msg, "panic: boom"); n != maxStressPanics {
+		t.Errorf("error has %d stacks, want the first %d", n, maxStressPanics)
+	}
+}
+
+func TestStressNoPanic(t *testing.T) {
+	LeakCheck(t)
+	var calls int64
+	f := runStress(func() { atomic.AddInt64(&calls, 1) }, 8, 50)
+	if len(f.errors) != 0 {
+		t.Errorf("unexpected errors: %q", f.errors)
+	}
+	if calls != 400 {
+		t.Errorf("fn called %d times, want 400", calls)
+	}
+}
+
+func TestStressBadArguments(t *testing.T) {
+	for _, args := range [][2]int{{0, 10}, {-1, 10}, {1, -1}} {
+		f := runStress(func() {}, args[0], args[1])
+		if !f.fatal {
+			t.Errorf("Stress(parallelism=%d, iterations=%d) did not fail", args[0], args[1])
+		}
+	}
+}
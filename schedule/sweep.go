@@ -0,0 +1,29 @@
+package schedule
+
+import "runtime"
+
+// SweepPoint 是 SweepProcs 的一个采样点
+type SweepPoint struct {
+	Procs      int
+	Throughput int64
+}
+
+// SweepProcs 依次设置 GOMAXPROCS 为 1..maxProcs, 调用 per(procs) 得到吞吐量
+//
+// 返回前(包括 per panic 的时候)都会恢复原来的 GOMAXPROCS, panic 会继续往上抛. maxProcs <= 0 返回空的 slice
+func SweepProcs(maxProcs int, per func(procs int) int64) []SweepPoint {
+	if maxProcs <= 0 {
+		return []SweepPoint{}
+	}
+
+	// defer 在 panic 展开栈的时候也会执行, 不需要 recover
+	prev := runtime.GOMAXPROCS(0)
+	defer runtime.GOMAXPROCS(prev)
+
+	points := make([]SweepPoint, 0, maxProcs)
+	for procs := 1; procs <= maxProcs; procs++ {
+		runtime.GOMAXPROCS(procs)
+		points = append(points, SweepPoint{Procs: procs, Throughput: per(procs)})
+	}
+	return points
+}
@@ -0,0 +1,73 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSweepProcs(t *testing.T) {
+	prev := runtime.GOMAXPROCS(0)
+	points := SweepProcs(3, func(procs int) int64 {
+		if got := runtime.GOMAXPROCS(0); got != procs {
+			t.Errorf("per(%d) called with GOMAXPROCS %d", procs, got)
+		}
+		return int64(10 * procs)
+	})
+	want := []SweepPoint{{1, 10}, {2, 20}, {3, 30}}
+	if fmt.Sprint(points) != fmt.Sprint(want) {
+		t.Errorf("points = %v, want %v", points, want)
+	}
+	if got := runtime.GOMAXPROCS(0); got != prev {
+		t.Errorf("GOMAXPROCS = %d after SweepProcs, want %d", got, prev)
+	}
+}
+
+func TestSweepProcsNoProcs(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		called := false
+		points := SweepProcs(n, func(int) int64 { called = true; return 0 })
+		if points == nil || len(points) != 0 || called {
+			t.Errorf("SweepProcs(%d) = %#v, called per: %t; want an empty slice without calling per", n, points, called)
+		}
+	}
+}
+
+func TestSweepProcsRestoresOnPanic(t *testing.T) {
+	prev := runtime.GOMAXPROCS(0)
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recovered %v, want the panic from per to propagate", r)
+		}
+		if got := runtime.GOMAXPROCS(0); got != prev {
+			t.Errorf("GOMAXPROCS = %d after panic, want %d", got, prev)
+		}
+	}()
+	SweepProcs(prev+2, func(procs int) int64 {
+		if procs == prev+1 {
+			panic("boom")
+		}
+		return 0
+	})
+}
+
+// BenchmarkSweepProcs 用 RunStarvationYield 在1到 NumCPU 个P上各跑 sweepWindow, 每个P数的吞吐量作为一个 metric 输出
+//
+//	go test -run '^$' -bench SweepProcs ./schedule
+func BenchmarkSweepProcs(b *testing.B) {
+	const sweepWindow = 20 * time.Millisecond
+	var points []SweepPoint
+	for i := 0; i < b.N; i++ {
+		points = SweepProcs(runtime.NumCPU(), func(procs int) int64 {
+			ctx, cancel := context.WithTimeout(context.Background(), sweepWindow)
+			defer cancel()
+			_, iterations := RunStarvationYield(ctx, procs, runtime.Gosched)
+			return iterations
+		})
+	}
+	for _, p := range points {
+		b.ReportMetric(float64(p.Throughput), fmt.Sprintf("iters/%dP", p.Procs))
+	}
+}
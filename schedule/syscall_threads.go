@@ -0,0 +1,63 @@
+package schedule
+
+import (
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// syscallBlockTime 是每个G阻塞在系统调用里的时间
+const syscallBlockTime = 100 * time.Millisecond
+
+// ThreadReport 是 BlockingSyscallThreads 的结果
+type ThreadReport struct {
+	// threadcreate profile 的计数, 即进程到目前为止创建过的线程数
+	ThreadsBefore int `json:"threads_before"`
+	ThreadsAfter  int `json:"threads_after"`
+	// 是否真的用了阻塞的系统调用, 不支持的平台退化成 time.Sleep, 不会产生新的M
+	RawSyscall bool `json:"raw_syscall"`
+}
+
+// BlockingSyscallThreads 在 GOMAXPROCS(procs) 下让 blockers 个G同时阻塞在系统调用里(linux 上是 nanosleep)
+//
+// G进入系统调用的时候 entersyscall 只是把P的状态改成 _Psyscall, M带着G一起阻塞在内核里.
+// sysmon 发现P在系统调用里待了超过20us, 就会 retake 这个P, 通过 handoffp 交给别的M(没有空闲的就新建一个)继续运行其他G.
+// 所以 blockers > procs 的时候, 线程数会明显增加. procs < 1 或者 blockers < 0 返回错误
+func BlockingSyscallThreads(procs, blockers int) (ThreadReport, error) {
+	opts := Options{Procs: procs, Duration: syscallBlockTime}
+	if err := opts.Validate(); err != nil && !IsWarning(err) {
+		return ThreadReport{}, err
+	}
+	if err := validateMin("blockers", blockers, 0); err != nil {
+		return ThreadReport{}, err
+	}
+
+	prev := runtime.GOMAXPROCS(opts.Procs)
+	defer runtime.GOMAXPROCS(prev)
+
+	threads := pprof.Lookup("threadcreate")
+	r := ThreadReport{
+		ThreadsBefore: threads.Count(),
+		RawSyscall:    rawBlockingSleepSupported,
+	}
+
+	var (
+		wg   sync.WaitGroup
+		gate StartGate
+	)
+	wg.Add(blockers)
+	gate.Add(blockers)
+	for i := 0; i < blockers; i++ {
+		go func() {
+			defer wg.Done()
+			gate.Ready()
+			blockingSleep(syscallBlockTime)
+		}()
+	}
+	gate.Go()
+	wg.Wait()
+
+	r.ThreadsAfter = threads.Count()
+	return r, nil
+}
@@ -0,0 +1,20 @@
+package schedule
+
+import (
+	"syscall"
+	"time"
+)
+
+const rawBlockingSleepSupported = true
+
+// blockingSleep 直接调用 nanosleep, 不经过 runtime 的定时器, M会一直阻塞在内核里
+func blockingSleep(d time.Duration) {
+	ts := syscall.NsecToTimespec(int64(d))
+	for {
+		// 被信号(比如异步抢占的 SIGURG)打断的时候 leftover 是剩下的时间, 接着睡
+		err := syscall.Nanosleep(&ts, &ts)
+		if err != syscall.EINTR {
+			return
+		}
+	}
+}
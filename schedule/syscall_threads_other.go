@@ -0,0 +1,12 @@
+//go:build !linux
+
+package schedule
+
+import "time"
+
+const rawBlockingSleepSupported = false
+
+// blockingSleep 在没有实现的平台上退化成 time.Sleep, G被挂起, M不会阻塞, 也就观察不到新建线程
+func blockingSleep(d time.Duration) {
+	time.Sleep(d)
+}
@@ -0,0 +1,35 @@
+package schedule
+
+import (
+	"fmt"
+	"testing"
+)
+
+const syscallBlockers = 16
+
+// syscallThreadsTestChild 以 procs=1 跑 BlockingSyscallThreads, 输出 "ThreadsBefore ThreadsAfter".
+// 放在子进程里是因为 threadcreate 只增不减, 同一个进程里之前的测试留下的空闲M会被直接用掉
+func syscallThreadsTestChild(string) {
+	r, err := BlockingSyscallThreads(1, syscallBlockers)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(r.ThreadsBefore, r.ThreadsAfter)
+}
+
+// 阻塞在 nanosleep 里的M带着G一起睡, sysmon 把P交出去之后要新建M才能让其他G进入系统调用
+func TestBlockingSyscallThreads(t *testing.T) {
+	LeakCheck(t)
+	if !rawBlockingSleepSupported {
+		t.Skip("blockingSleep falls back to time.Sleep, no new threads expected")
+	}
+	out := runChild(t, "test-syscall-threads", "")
+	var before, after int
+	if _, err := fmt.Sscan(out, &before, &after); err != nil {
+		t.Fatalf("child output %q: %v", out, err)
+	}
+	if after <= before {
+		t.Errorf("threads before %d, after %d, want new threads when blockers > procs", before, after)
+	}
+}
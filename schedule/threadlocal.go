@@ -0,0 +1,80 @@
+package schedule
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// threadLocalSteps 是 ThreadLocalDemo 里每个G记录线程id的次数
+const threadLocalSteps = 50
+
+// ThreadLocalReport 是 ThreadLocalDemo 的结果
+type ThreadLocalReport struct {
+	// LockOSThread 的G是不是一直在同一个线程上
+	LockedStayedOnOneThread bool `json:"locked_stayed_on_one_thread"`
+	// 没有 LockOSThread 的G是不是换过线程
+	UnlockedMigrated bool  `json:"unlocked_migrated"`
+	LockedTids       []int `json:"locked_tids"`
+	UnlockedTids     []int `json:"unlocked_tids"`
+}
+
+// ThreadLocalDemo 分别在 LockOSThread 和没有 LockOSThread 的G里, 每次 Gosched 或者 sleep 之后记录一次当前的线程id
+//
+// 有些系统 API 的状态是线程局部的(比如 OpenGL 的 context, linux 的 setns, 一些 cgo 库), 必须在同一个线程上调用.
+// 普通的G每次被重新调度都可能换到另一个M上, 只有 LockOSThread 之后才能保证一直在同一个线程上.
+// 没有 LockOSThread 的G不一定每次都会换线程, 单核机器上可能观察不到迁移. 目前只支持 linux, 其他平台返回 ErrUnsupported
+func ThreadLocalDemo() (ThreadLocalReport, error) {
+	var r ThreadLocalReport
+	if !gettidSupported {
+		return r, ErrUnsupported
+	}
+
+	// 至少两个P, 这样才有别的M可以接手
+	procs := runtime.NumCPU()
+	if procs < 2 {
+		procs = 2
+	}
+	prev := runtime.GOMAXPROCS(procs)
+	defer runtime.GOMAXPROCS(prev)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		r.LockedTids = recordTids()
+	}()
+	go func() {
+		defer wg.Done()
+		r.UnlockedTids = recordTids()
+	}()
+	wg.Wait()
+
+	r.LockedStayedOnOneThread = distinct(r.LockedTids) == 1
+	r.UnlockedMigrated = distinct(r.UnlockedTids) > 1
+	return r, nil
+}
+
+func recordTids() []int {
+	tids := make([]int, 0, threadLocalSteps)
+	for i := 0; i < threadLocalSteps; i++ {
+		tids = append(tids, gettid())
+		// 交替主动让出和阻塞, 阻塞醒来之后可能被任意一个M运行
+		if i%2 == 0 {
+			runtime.Gosched()
+		} else {
+			time.Sleep(100 * time.Microsecond)
+		}
+	}
+	return tids
+}
+
+func distinct(ids []int) int {
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	return len(seen)
+}
@@ -0,0 +1,9 @@
+package schedule
+
+import "golang.org/x/sys/unix"
+
+const gettidSupported = true
+
+func gettid() int {
+	return unix.Gettid()
+}
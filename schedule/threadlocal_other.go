@@ -0,0 +1,9 @@
+//go:build !linux
+
+package schedule
+
+const gettidSupported = false
+
+func gettid() int {
+	return 0
+}
@@ -0,0 +1,27 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestThreadLocalDemo(t *testing.T) {
+	LeakCheck(t)
+	r, err := ThreadLocalDemo()
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.LockedTids) != threadLocalSteps || len(r.UnlockedTids) != threadLocalSteps {
+		t.Fatalf("recorded %d locked and %d unlocked tids, want %d each", len(r.LockedTids), len(r.UnlockedTids), threadLocalSteps)
+	}
+	if n := distinct(r.LockedTids); n != 1 || !r.LockedStayedOnOneThread {
+		t.Errorf("locked goroutine ran on %d threads: %v", n, r.LockedTids)
+	}
+	// 没有 LockOSThread 的G可能换线程, 也可能不换, 只检查 UnlockedMigrated 和记录的一致
+	if r.UnlockedMigrated != (distinct(r.UnlockedTids) > 1) {
+		t.Errorf("UnlockedMigrated = %t, tids %v", r.UnlockedMigrated, r.UnlockedTids)
+	}
+}
@@ -0,0 +1,117 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"runtime/trace"
+
+	xtrace "golang.org/x/exp/trace"
+)
+
+// maxTraceBytes 是 CaptureTrace 内存缓冲的上限
+const maxTraceBytes = 64 << 20
+
+// ErrTraceTooLarge 表示 trace 数据超过了 maxTraceBytes
+var ErrTraceTooLarge = errors.New("schedule: trace exceeds 64MB buffer")
+
+// SchedSummary 是从 trace 里统计出来的G状态变化
+type SchedSummary struct {
+	// NotExist -> Runnable/Waiting, 即 newproc
+	GoroutineCreated int `json:"goroutine_created"`
+	// Running -> Waiting, 即 gopark (channel, sleep, mutex, 网络等)
+	Blocks int `json:"blocks"`
+	// Running -> Runnable 并且原因是被抢占(包括 sysmon 的协作式抢占和信号异步抢占), 不包括主动 Gosched
+	Preemptions int `json:"preemptions"`
+	// 按 goroutine id 分开的 Blocks 和 Preemptions, id 和 runtime.Stack 里 "goroutine 42" 的一样
+	ByGoroutine map[int64]GoroutineSched `json:"by_goroutine"`
+}
+
+// GoroutineSched 是 SchedSummary 里单个G的统计
+type GoroutineSched struct {
+	Blocks      int `json:"blocks"`
+	Preemptions int `json:"preemptions"`
+}
+
+// CaptureTrace 把 runtime/trace 写到内存里, 执行 workload, 然后解析 trace 得到调度的统计信息
+//
+// trace 已经被别人开启(比如 go test -trace)的时候 trace.Start 会失败, 这时直接返回错误.
+// workload panic 的时候 trace 也会被关掉, 不影响之后再调用 CaptureTrace
+func CaptureTrace(ctx context.Context, workload func()) (SchedSummary, error) {
+	if err := ctx.Err(); err != nil {
+		return SchedSummary{}, err
+	}
+
+	buf := &boundedBuffer{max: maxTraceBytes}
+	if err := trace.Start(buf); err != nil {
+		return SchedSummary{}, err
+	}
+	func() {
+		defer trace.Stop()
+		trace.WithRegion(ctx, "workload", workload)
+	}()
+
+	if buf.overflow {
+		return SchedSummary{}, ErrTraceTooLarge
+	}
+	return summarizeTrace(&buf.buf)
+}
+
+func summarizeTrace(r io.Reader) (SchedSummary, error) {
+	s := SchedSummary{ByGoroutine: make(map[int64]GoroutineSched)}
+
+	reader, err := xtrace.NewReader(r)
+	if err != nil {
+		return s, err
+	}
+	for {
+		ev, err := reader.ReadEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return s, err
+		}
+		if ev.Kind() != xtrace.EventStateTransition {
+			continue
+		}
+		st := ev.StateTransition()
+		if st.Resource.Kind != xtrace.ResourceGoroutine {
+			continue
+		}
+		id := int64(st.Resource.Goroutine())
+		from, to := st.Goroutine()
+		switch {
+		case from == xtrace.GoNotExist && (to == xtrace.GoRunnable || to == xtrace.GoWaiting):
+			s.GoroutineCreated++
+		case from == xtrace.GoRunning && to == xtrace.GoWaiting:
+			s.Blocks++
+			g := s.ByGoroutine[id]
+			g.Blocks++
+			s.ByGoroutine[id] = g
+		case from == xtrace.GoRunning && to == xtrace.GoRunnable && st.Reason == "preempted":
+			s.Preemptions++
+			g := s.ByGoroutine[id]
+			g.Preemptions++
+			s.ByGoroutine[id] = g
+		}
+	}
+	return s, nil
+}
+
+// boundedBuffer 超过 max 之后丢弃后面的数据并记录 overflow,
+// runtime/trace 会忽略 Write 返回的错误, 所以只能在 Stop 之后再检查
+type boundedBuffer struct {
+	buf      bytes.Buffer
+	max      int
+	overflow bool
+}
+
+func (b *boundedBuffer) Write(p []byte) (int, error) {
+	if b.overflow || b.buf.Len()+len(p) > b.max {
+		b.overflow = true
+		return 0, ErrTraceTooLarge
+	}
+	return b.buf.Write(p)
+}
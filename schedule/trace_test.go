@@ -0,0 +1,118 @@
+package schedule
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"runtime/trace"
+	"testing"
+	"time"
+)
+
+// goid 返回当前G的id, 和 trace 里的 goroutine id 是同一个
+func goid(t *testing.T) int64 {
+	t.Helper()
+	var buf [64]byte
+	id, ok := parseGoroutineHeader(string(buf[:runtime.Stack(buf[:], false)]))
+	if !ok {
+		t.Fatalf("cannot parse goroutine id from %q", buf)
+	}
+	return int64(id)
+}
+
+func skipIfTracing(t *testing.T) {
+	if trace.IsEnabled() {
+		t.Skip("runtime/trace already enabled (go test -trace?)")
+	}
+}
+
+func TestCaptureTraceStarvedGoroutine(t *testing.T) {
+	LeakCheck(t)
+	skipIfTracing(t)
+
+	var busy, observer int64
+	s, err := CaptureTrace(context.Background(), func() {
+		prev := runtime.GOMAXPROCS(1)
+		defer runtime.GOMAXPROCS(prev)
+
+		done := make(chan struct{})
+		busyID, observerID := make(chan int64, 1), make(chan int64, 1)
+		go func() {
+			defer close(done)
+			busyID <- goid(t)
+			deadline := time.Now().Add(100 * time.Millisecond)
+			for time.Now().Before(deadline) {
+			}
+		}()
+		go func() {
+			observerID <- goid(t)
+			for {
+				select {
+				case <-done:
+					return
+				case <-time.After(time.Millisecond):
+				}
+			}
+		}()
+		<-done
+		busy, observer = <-busyID, <-observerID
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.GoroutineCreated < 2 {
+		t.Errorf("GoroutineCreated = %d, want >= 2", s.GoroutineCreated)
+	}
+
+	if got := s.ByGoroutine[busy].Preemptions; got == 0 {
+		t.Errorf("busy goroutine preemptions = 0, want > 0 (summary %+v)", s)
+	}
+	if got := s.ByGoroutine[observer].Preemptions; got != 0 {
+		t.Errorf("starved goroutine preemptions = %d, want 0", got)
+	}
+	if s.Preemptions < s.ByGoroutine[busy].Preemptions {
+		t.Errorf("total preemptions %d < per-goroutine %d", s.Preemptions, s.ByGoroutine[busy].Preemptions)
+	}
+}
+
+func TestCaptureTraceWorkloadPanic(t *testing.T) {
+	LeakCheck(t)
+	skipIfTracing(t)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("workload panic was swallowed")
+			}
+		}()
+		CaptureTrace(context.Background(), func() { panic("boom") })
+	}()
+	if trace.IsEnabled() {
+		t.Fatal("trace still enabled after workload panic")
+	}
+	if _, err := CaptureTrace(context.Background(), func() {}); err != nil {
+		t.Errorf("CaptureTrace after panic: %v", err)
+	}
+}
+
+func TestCaptureTraceAlreadyStarted(t *testing.T) {
+	skipIfTracing(t)
+
+	if err := trace.Start(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	defer trace.Stop()
+	if _, err := CaptureTrace(context.Background(), func() {}); err == nil {
+		t.Error("CaptureTrace succeeded while trace was already running")
+	}
+}
+
+func TestCaptureTraceCanceled(t *testing.T) {
+	LeakCheck(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ran := false
+	if _, err := CaptureTrace(ctx, func() { ran = true }); err == nil || ran {
+		t.Errorf("canceled ctx: err = %v, workload ran = %v", err, ran)
+	}
+}
@@ -0,0 +1,105 @@
+package schedule
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chidaren/read-go-source-code/schedule/schedtrace"
+)
+
+const (
+	// unstealableBlocked 是子进程里阻塞在 channel 上的G的个数
+	unstealableBlocked = 16
+	// maxUnstealableBusy 是 UnstealableLoad 里忙碌的G最多跑多久
+	maxUnstealableBusy = time.Second
+)
+
+// UnstealableReport 是 UnstealableLoad 的结果
+type UnstealableReport struct {
+	// 有P在忙的采样里, 最多有几个P是空闲的
+	IdleProcsObserved int `json:"idle_procs_observed"`
+	// 子进程里阻塞在 channel 上的G的个数
+	BlockedGoroutines int `json:"blocked_goroutines"`
+}
+
+// UnstealableLoad 在子进程里以 GOMAXPROCS(procs) 让一个G忙 d, 同时有 unstealableBlocked 个G一直阻塞在 channel 上, 通过 schedtrace 观察空闲的P
+//
+// work stealing 只能偷 runq 里 runnable 的G, 阻塞在 channel 上的G挂在 hchan 的 recvq 上, 不在任何一个P的队列里,
+// 所以G再多, 除了忙的那个P之外其他P都找不到活干, 只能空闲. d 超过 maxUnstealableBusy 的时候按 maxUnstealableBusy 算.
+// procs 比CPU核数多也照样用, GOMAXPROCS 超过核数是合法的, 单核机器上也能看到多余的P空闲
+func UnstealableLoad(procs int, d time.Duration) (UnstealableReport, error) {
+	var r UnstealableReport
+
+	opts := Options{Procs: procs, Duration: d}
+	if err := opts.Validate(); err != nil && !IsWarning(err) {
+		return r, err
+	}
+	if opts.Duration > maxUnstealableBusy {
+		opts.Duration = maxUnstealableBusy
+	}
+
+	// 不用 Validate 按 NumCPU 改过的 Procs
+	cmd, err := childCommand("unstealable", fmt.Sprintf("%d,%d", procs, opts.Duration), "schedtrace=1")
+	if err != nil {
+		return r, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return r, fmt.Errorf("schedule: unstealable child: %v: %s", err, stderr.Bytes())
+	}
+
+	if r.BlockedGoroutines, err = strconv.Atoi(strings.TrimSpace(stdout.String())); err != nil {
+		return r, fmt.Errorf("schedule: unstealable child output %q: %v", stdout.Bytes(), err)
+	}
+
+	lines, err := schedtrace.Parse(&stderr)
+	if err != nil {
+		return r, err
+	}
+	for _, l := range lines {
+		// 所有P都空闲的采样是忙碌的G开始之前或者结束之后的, 不算
+		if l.IdleProcs < l.Gomaxprocs && l.IdleProcs > r.IdleProcsObserved {
+			r.IdleProcsObserved = l.IdleProcs
+		}
+	}
+	return r, nil
+}
+
+// unstealableChild 的参数是 "procs,busyNanos", 阻塞的G的个数写到 stdout
+func unstealableChild(arg string) {
+	var procs int
+	var busy time.Duration
+	if i := strings.IndexByte(arg, ','); i >= 0 {
+		procs, _ = strconv.Atoi(arg[:i])
+		n, _ := strconv.ParseInt(arg[i+1:], 10, 64)
+		busy = time.Duration(n)
+	}
+	if procs > 0 {
+		runtime.GOMAXPROCS(procs)
+	}
+
+	before := runtime.NumGoroutine()
+	block := make(chan struct{})
+	for i := 0; i < unstealableBlocked; i++ {
+		go func() { <-block }()
+	}
+	// 等它们都跑到 <-block 上
+	time.Sleep(time.Millisecond)
+	fmt.Println(runtime.NumGoroutine() - before)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		deadline := time.Now().Add(busy)
+		for time.Now().Before(deadline) {
+		}
+	}()
+	<-done
+	close(block)
+}
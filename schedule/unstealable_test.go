@@ -0,0 +1,26 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnstealableLoad(t *testing.T) {
+	LeakCheck(t)
+	for _, procs := range []int{2, 4} {
+		r, err := UnstealableLoad(procs, 100*time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.BlockedGoroutines != unstealableBlocked {
+			t.Errorf("procs=%d: BlockedGoroutines = %d, want %d", procs, r.BlockedGoroutines, unstealableBlocked)
+		}
+		// 只有一个P在忙, 其他P都没有G可偷
+		if r.IdleProcsObserved == 0 {
+			t.Errorf("procs=%d: IdleProcsObserved = 0, want > 0", procs)
+		}
+		if r.IdleProcsObserved > procs-1 {
+			t.Errorf("procs=%d: IdleProcsObserved = %d while one P is busy", procs, r.IdleProcsObserved)
+		}
+	}
+}
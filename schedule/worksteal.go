@@ -0,0 +1,139 @@
+package schedule
+
+import (
+	"runtime"
+	"sync"
+	"time"
+	_ "unsafe" // go:linkname
+)
+
+// stealWork 是 WorkSteal 里每个G循环的次数, 每个G的工作量是固定的, 干完就退出
+const stealWork = 1 << 20
+
+// StealStats 是 WorkSteal 的结果
+type StealStats struct {
+	// 每个G开始运行时所在的P的 id
+	PerGoroutineP []int `json:"per_goroutine_p"`
+	// 每个P上开始运行的G的个数, 下标是P的 id
+	PerPGoroutines []int64 `json:"per_p_goroutines"`
+	// 不在编排者所在的P上开始运行的G的个数, 也就是被偷走或者从全局队列拿走的
+	Stolen int `json:"stolen"`
+	// PerPGoroutines 的 (max - min) / mean, 0 表示完全平均, 都没被偷走的时候等于 procs
+	Imbalance float64 `json:"imbalance"`
+	// PerPGoroutines 的 Jain 公平性指数
+	Fairness float64 `json:"fairness"`
+	// 采样到的最大 runtime.NumGoroutine()
+	PeakGoroutines int `json:"peak_goroutines"`
+}
+
+// procPin 返回当前G所在的P的 id, 同时禁止抢占, 必须马上调用 procUnpin
+//
+//go:linkname procPin runtime.procPin
+func procPin() int
+
+//go:linkname procUnpin runtime.procUnpin
+func procUnpin()
+
+func currentP() int {
+	id := procPin()
+	procUnpin()
+	return id
+}
+
+// WorkSteal 在 GOMAXPROCS(procs) 下, 由同一个G连续创建 numGoroutines 个G, 每个G循环 stealWork 次之后退出.
+// 这些G都会进到创建者所在P的本地队列(满了256个之后放到全局队列), 其他P只能通过偷或者从全局队列拿来执行
+//
+// 每个G开始的时候记下自己所在的P, 所以统计的是G被分到了哪些P上, 和异步抢占的时间片无关.
+// procs = 1 的时候只有一个P, Imbalance 总是0; procs > 1 的时候偷得越多 Imbalance 越小.
+// procs 比CPU核数多也照样用. 所有G干完活才返回, 不会留下G. numGoroutines < 1 或者 procs < 1 返回错误
+func WorkSteal(numGoroutines, procs int) (StealStats, error) {
+	if err := validateMin("numGoroutines", numGoroutines, 1); err != nil {
+		return StealStats{}, err
+	}
+	opts := Options{Procs: procs, Duration: time.Second}
+	if err := opts.Validate(); err != nil && !IsWarning(err) {
+		return StealStats{}, err
+	}
+
+	// 和 UnstealableLoad 一样不用 Validate 按 NumCPU 改过的 Procs, 单核机器上多个P之间也会偷
+	prev := runtime.GOMAXPROCS(procs)
+	defer runtime.GOMAXPROCS(prev)
+
+	var (
+		startP = make([]int, numGoroutines)
+		spins  = make([]int64, numGoroutines)
+		wg     sync.WaitGroup
+		gate   StartGate
+	)
+
+	wg.Add(numGoroutines)
+	gate.Add(numGoroutines)
+	// 从一个G里面创建, 保证都在同一个P的队列里. Go() 放行的时候所有G也是被编排者所在的P唤醒的
+	go func() {
+		for i := 0; i < numGoroutines; i++ {
+			go func(i int) {
+				defer wg.Done()
+				gate.Ready()
+				startP[i] = currentP()
+				var n int64
+				for j := 0; j < stealWork; j++ {
+					n++
+				}
+				// 写到 spins 里, 免得循环被当成没用的代码优化掉
+				spins[i] = n
+			}(i)
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	gate.Go()
+	self := currentP()
+	peak := 0
+	for waiting := true; waiting; {
+		if n := runtime.NumGoroutine(); n > peak {
+			peak = n
+		}
+		select {
+		case <-done:
+			waiting = false
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	r := StealStats{PerGoroutineP: startP, PerPGoroutines: make([]int64, runtime.GOMAXPROCS(0)), PeakGoroutines: peak}
+	for _, p := range startP {
+		r.PerPGoroutines[p]++
+		if p != self {
+			r.Stolen++
+		}
+	}
+	r.Imbalance = imbalance(r.PerPGoroutines)
+	r.Fairness = JainFairness(r.PerPGoroutines)
+	return r, nil
+}
+
+func imbalance(counts []int64) float64 {
+	if len(counts) == 0 {
+		return 0
+	}
+	lo, hi, sum := counts[0], counts[0], int64(0)
+	for _, c := range counts {
+		if c < lo {
+			lo = c
+		}
+		if c > hi {
+			hi = c
+		}
+		sum += c
+	}
+	if sum == 0 {
+		return 0
+	}
+	mean := float64(sum) / float64(len(counts))
+	return float64(hi-lo) / mean
+}
@@ -0,0 +1,55 @@
+package schedule
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// 每个G的工作量是固定的, 不管 procs 是多少都要在超时之前全部干完
+func TestWorkSteal(t *testing.T) {
+	LeakCheck(t)
+	const n = 100
+	for _, procs := range []int{1, 2, 4} {
+		t.Run(fmt.Sprintf("procs=%d", procs), func(t *testing.T) {
+			done := make(chan StealStats)
+			go func() {
+				r, err := WorkSteal(n, procs)
+				if err != nil {
+					t.Error(err)
+				}
+				done <- r
+			}()
+			var r StealStats
+			select {
+			case r = <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatal("WorkSteal did not finish, some goroutine starved?")
+			}
+
+			if len(r.PerGoroutineP) != n || len(r.PerPGoroutines) != procs {
+				t.Fatalf("%d goroutines on %d Ps, want %d on %d", len(r.PerGoroutineP), len(r.PerPGoroutines), n, procs)
+			}
+			var sum int64
+			for _, c := range r.PerPGoroutines {
+				sum += c
+			}
+			if sum != n {
+				t.Errorf("sum(PerPGoroutines) = %d, want %d", sum, n)
+			}
+			if r.Imbalance < 0 || r.Imbalance > float64(procs) {
+				t.Errorf("Imbalance = %v, want in [0, %d]", r.Imbalance, procs)
+			}
+			if procs == 1 && (r.Stolen != 0 || r.Imbalance != 0) {
+				t.Errorf("procs=1: %+v, nothing can be stolen with one P", r)
+			}
+			// 其他P的 runq 是空的, findRunnable 一定会去偷, 100个G不可能一个都偷不到
+			if procs > 1 && r.Stolen == 0 {
+				t.Errorf("procs=%d: %+v, nothing was stolen", procs, r)
+			}
+			if r.PeakGoroutines < 1 {
+				t.Errorf("PeakGoroutines = %d", r.PeakGoroutines)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package schedule
+
+import "runtime"
+
+// YieldReport 是 YieldVariants 的结果
+type YieldReport struct {
+	// runtime.Gosched() 之后G继续执行了后面的代码
+	GoschedResumed bool `json:"gosched_resumed"`
+	// runtime.Goexit() 执行了 defer, 而且后面的代码没有执行
+	GoexitRanDefers bool `json:"goexit_ran_defers"`
+}
+
+// YieldVariants 对比 runtime.Gosched 和 runtime.Goexit
+//
+// Gosched 把当前G放回全局队列, 让出P, 之后还会被调度回来继续执行;
+// Goexit 终止当前G, 和 panic 一样会执行所有的 defer, 但是不会返回到调用方, 也不能被 recover.
+// 在 main 函数的G里调用 Goexit, main 永远不会返回, 其他G都退出之后程序会因为死锁崩溃, 所以这里只在新建的G里调用
+func YieldVariants() YieldReport {
+	var r YieldReport
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runtime.Gosched()
+		r.GoschedResumed = true
+	}()
+	<-done
+
+	var deferred, returned bool
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() { deferred = true }()
+		goexit()
+		// 不会执行到这里
+		returned = true
+	}()
+	<-done
+
+	r.GoexitRanDefers = deferred && !returned
+	return r
+}
+
+// goexit 在一个单独的函数里调用 runtime.Goexit, 说明它不会返回到调用方
+func goexit() {
+	runtime.Goexit()
+}
@@ -0,0 +1,21 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// Goexit 只在新建的G里调用, 调用方不会被结束, 也不会死锁
+func TestYieldVariants(t *testing.T) {
+	LeakCheck(t)
+	done := make(chan YieldReport)
+	go func() { done <- YieldVariants() }()
+	select {
+	case r := <-done:
+		if !r.GoschedResumed || !r.GoexitRanDefers {
+			t.Errorf("%+v, want both true", r)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("YieldVariants did not return, deadlocked?")
+	}
+}
@@ -0,0 +1,66 @@
+// Package timers 观察 runtime 定时器(time.Sleep, time.Timer)的精度
+package timers
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+
+time.Sleep 把当前G挂起, 在P的定时器堆(go1.14 之前是全局的 timersBucket)里加一个定时器, 到期之后由 checkTimers 唤醒G.
+checkTimers 是在调度循环(schedule, findrunnable)里被调用的, 如果P一直被一个不让出的G占着, 定时器到期了也没人去检查,
+只能等 sysmon 抢占或者别的P来偷, 所以 sleep 的实际时间会比要求的长
+
+*/
+
+// SleepReport 是 SleepAccuracy 的结果, 超出的时间都是相对要求的 sleep 时间
+type SleepReport struct {
+	MeanOvershootNanos int64
+	MaxOvershootNanos  int64
+	// 超出时间的99分位
+	P99Nanos int64
+}
+
+var errSamples = errors.New("timers: samples must be > 0")
+
+// SleepAccuracy 连续 sleep samples 次 target, 统计每次实际 sleep 的时间超出 target 多少
+//
+// load 不为 nil 的时候会在另一个G里同时运行, 用来制造背景负载, stop 关闭之后 load 应该尽快返回.
+// 每次都重新取开始时间, 前一次的误差不会累积到后一次
+func SleepAccuracy(target time.Duration, samples int, load func(stop <-chan struct{})) (SleepReport, error) {
+	var r SleepReport
+	if samples <= 0 {
+		return r, errSamples
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	if load != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			load(stop)
+		}()
+	}
+
+	over := make([]int64, samples)
+	var sum int64
+	for i := range over {
+		start := time.Now()
+		time.Sleep(target)
+		o := int64(time.Since(start) - target)
+		over[i] = o
+		sum += o
+	}
+	close(stop)
+	wg.Wait()
+
+	sort.Slice(over, func(i, j int) bool { return over[i] < over[j] })
+	r.MeanOvershootNanos = sum / int64(samples)
+	r.MaxOvershootNanos = over[samples-1]
+	r.P99Nanos = over[(samples-1)*99/100]
+	return r, nil
+}
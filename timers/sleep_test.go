@@ -0,0 +1,61 @@
+package timers
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chidaren/read-go-source-code/schedule"
+)
+
+func TestSleepAccuracyBadSamples(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		called := false
+		_, err := SleepAccuracy(time.Millisecond, n, func(<-chan struct{}) { called = true })
+		if !errors.Is(err, errSamples) {
+			t.Errorf("samples=%d: err = %v, want %v", n, err, errSamples)
+		}
+		if called {
+			t.Errorf("samples=%d: load was started", n)
+		}
+	}
+}
+
+func TestSleepAccuracy(t *testing.T) {
+	schedule.LeakCheck(t)
+	r, err := SleepAccuracy(time.Millisecond, 20, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// time.Sleep 不会提前返回
+	if r.MeanOvershootNanos < 0 || r.P99Nanos < 0 || r.MaxOvershootNanos < r.P99Nanos {
+		t.Errorf("%+v, want 0 <= P99 <= Max and a non-negative mean", r)
+	}
+}
+
+// load 所在的G必须在 SleepAccuracy 返回之前退出
+func TestSleepAccuracyStopsLoad(t *testing.T) {
+	schedule.LeakCheck(t)
+	var running, exited int32
+	load := func(stop <-chan struct{}) {
+		atomic.StoreInt32(&running, 1)
+		defer atomic.StoreInt32(&exited, 1)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+		}
+	}
+	if _, err := SleepAccuracy(time.Millisecond, 20, load); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&running) == 0 {
+		t.Error("load never ran")
+	}
+	if atomic.LoadInt32(&exited) == 0 {
+		t.Error("SleepAccuracy returned before load exited")
+	}
+}